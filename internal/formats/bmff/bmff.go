@@ -0,0 +1,414 @@
+// Package bmff provides C2PA detection/removal for ISO BMFF-based
+// containers (HEIF, AVIF, MP4/MOV), which carry C2PA manifests in a
+// top-level "uuid" box (the C2PA BMFF binding) or a top-level "jumb"
+// (JUMBF) box. Removing a box that sits before mdat shifts every later
+// byte back, so Remove also adjusts every byte reference that could now
+// point at the wrong place: moov's stco/co64 sample offset tables for
+// MP4/MOV, and meta's iloc item extents for HEIF/AVIF.
+package bmff
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/ngmisl/C2PAremover/internal/jumbf"
+	"github.com/ngmisl/C2PAremover/internal/segment"
+)
+
+// C2PAUUID is the BMFF binding's "uuid" box UUID for a C2PA manifest
+// (distinct from the JUMBF description-box UUID used inside "jumb" boxes).
+var C2PAUUID = [16]byte{
+	0xD8, 0xFE, 0xC3, 0xD6, 0x1B, 0x0E, 0x48, 0x3C,
+	0x92, 0x97, 0x58, 0x28, 0x87, 0x7E, 0xC4, 0x81,
+}
+
+// box is a top-level BMFF box as laid out on disk.
+type box struct {
+	typ    string
+	start  int
+	header int // size of the size+type(+largesize) header
+	size   int // total box size on disk, including the header
+}
+
+// walkTopBoxes walks the top-level box sequence (32-bit size + 4-byte
+// type, promoted to a 64-bit largesize when size == 1).
+func walkTopBoxes(data []byte) []box {
+	var boxes []box
+	pos := 0
+	for pos+8 <= len(data) {
+		size32 := binary.BigEndian.Uint32(data[pos : pos+4])
+		typ := string(data[pos+4 : pos+8])
+
+		header := 8
+		size := int(size32)
+		switch size32 {
+		case 1:
+			if pos+16 > len(data) {
+				return boxes
+			}
+			size = int(binary.BigEndian.Uint64(data[pos+8 : pos+16]))
+			header = 16
+		case 0:
+			size = len(data) - pos // box extends to EOF
+		}
+		if size < header || pos+size > len(data) {
+			return boxes
+		}
+
+		boxes = append(boxes, box{typ: typ, start: pos, header: header, size: size})
+		pos += size
+	}
+	return boxes
+}
+
+func isC2PABox(data []byte, b box) bool {
+	return isC2PAPayload(b.typ, data[b.start+b.header:b.start+b.size])
+}
+
+// isC2PAPayload reports whether a top-level box's payload (everything after
+// its size+type header) identifies it as a C2PA "uuid" or "jumb" box. It
+// takes just the payload, not the full box, so the streaming reader in
+// stream.go can call it without reconstructing the header bytes it never
+// had to buffer.
+func isC2PAPayload(typ string, payload []byte) bool {
+	switch typ {
+	case "uuid":
+		if len(payload) < 16 {
+			return false
+		}
+		var uuid [16]byte
+		copy(uuid[:], payload[:16])
+		return uuid == C2PAUUID
+	case "jumb":
+		// jumbf.ParseBox expects the box's own LBox/TBox header, which a
+		// "jumb" box's payload doesn't include; reconstruct a minimal one
+		// so the box parses as if read straight off disk.
+		header := make([]byte, 8)
+		binary.BigEndian.PutUint32(header[0:4], uint32(8+len(payload)))
+		copy(header[4:8], "jumb")
+		parsed, err := jumbf.ParseBox(append(header, payload...))
+		return err == nil && parsed.IsC2PA()
+	default:
+		return false
+	}
+}
+
+// Check reports whether data (a BMFF-based HEIF/AVIF/MP4/MOV file) carries
+// a top-level C2PA "uuid" or "jumb" box.
+func Check(data []byte) bool {
+	for _, b := range walkTopBoxes(data) {
+		if (b.typ == "uuid" || b.typ == "jumb") && isC2PABox(data, b) {
+			return true
+		}
+	}
+	return false
+}
+
+// Inspect reports every top-level C2PA "uuid" or "jumb" box in a BMFF-based
+// file.
+func Inspect(data []byte) []segment.Info {
+	var infos []segment.Info
+	for _, b := range walkTopBoxes(data) {
+		if (b.typ != "uuid" && b.typ != "jumb") || !isC2PABox(data, b) {
+			continue
+		}
+		info := segment.Info{Kind: b.typ, Offset: b.start, Length: b.size}
+		switch b.typ {
+		case "uuid":
+			info.UUID = fmt.Sprintf("%x", C2PAUUID[:])
+		case "jumb":
+			if parsed, err := jumbf.ParseBox(data[b.start : b.start+b.size]); err == nil {
+				if desc, ok := parsed.Description(); ok {
+					if uuid, ok := desc.UUID(); ok {
+						info.UUID = fmt.Sprintf("%x", uuid[:])
+					}
+				}
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// containerBoxTypes are BMFF boxes known to hold only sibling sub-boxes in
+// their payload (as opposed to opaque sample data like mdat), so it's safe
+// to recurse into them looking for stco/co64.
+var containerBoxTypes = map[string]bool{
+	"moov": true, "trak": true, "mdia": true, "minf": true,
+	"stbl": true, "dinf": true, "edts": true, "mvex": true,
+	"moof": true, "traf": true, "udta": true,
+}
+
+// Remove strips top-level C2PA boxes from a BMFF-based file, copying every
+// other box through byte-for-byte. If any removed box sits before mdat,
+// every byte after it shifts back by the removed size, so both sample
+// offsets recorded in moov's stco/co64 boxes (MP4/MOV) and file-based item
+// extents recorded in meta's iloc box (HEIF/AVIF) are adjusted by that same
+// delta to keep pointing at the right bytes.
+func Remove(data []byte) ([]byte, error) {
+	boxes := walkTopBoxes(data)
+	if len(boxes) == 0 {
+		return nil, fmt.Errorf("bmff: not a valid box-structured file")
+	}
+
+	mdatStart := -1
+	for _, b := range boxes {
+		if b.typ == "mdat" {
+			mdatStart = b.start
+			break
+		}
+	}
+
+	var result []byte
+	removed := false
+	delta := 0
+	moovStart := -1
+	for _, b := range boxes {
+		if (b.typ == "uuid" || b.typ == "jumb") && isC2PABox(data, b) {
+			removed = true
+			if mdatStart < 0 || b.start < mdatStart {
+				delta += b.size
+			}
+			continue
+		}
+		if b.typ == "moov" {
+			moovStart = len(result)
+		}
+		result = append(result, data[b.start:b.start+b.size]...)
+	}
+	if !removed {
+		return data, nil
+	}
+
+	if delta != 0 {
+		if moovStart >= 0 {
+			moovSize := boxSize(result[moovStart:])
+			patchChunkOffsets(result[moovStart:moovStart+moovSize], delta)
+		}
+		patchItemLocations(result, delta)
+	}
+	return result, nil
+}
+
+// boxSize reads a single box's LBox/XLBox size from the start of buf.
+func boxSize(buf []byte) int {
+	if len(buf) < 8 {
+		return len(buf)
+	}
+	size32 := binary.BigEndian.Uint32(buf[0:4])
+	if size32 == 1 && len(buf) >= 16 {
+		return int(binary.BigEndian.Uint64(buf[8:16]))
+	}
+	if size32 == 0 {
+		return len(buf)
+	}
+	return int(size32)
+}
+
+// patchChunkOffsets walks buf (a single box's full bytes: header+payload)
+// and, for every stco/co64 it finds - recursing into known container
+// boxes to reach them - subtracts delta from each recorded sample offset.
+func patchChunkOffsets(buf []byte, delta int) {
+	if len(buf) < 8 {
+		return
+	}
+	header := 8
+	if binary.BigEndian.Uint32(buf[0:4]) == 1 {
+		header = 16
+	}
+	for _, b := range walkTopBoxes(buf[header:]) {
+		switch b.typ {
+		case "stco":
+			patchStco(buf[header+b.start:header+b.start+b.size], b.header, delta)
+		case "co64":
+			patchCo64(buf[header+b.start:header+b.start+b.size], b.header, delta)
+		default:
+			if containerBoxTypes[b.typ] {
+				patchChunkOffsets(buf[header+b.start:header+b.start+b.size], delta)
+			}
+		}
+	}
+}
+
+// patchStco adjusts a "stco" box's 32-bit chunk offset table in place.
+// boxHeader is the size of its own size+type header (8, or 16 for a
+// largesize box).
+func patchStco(box []byte, boxHeader, delta int) {
+	if len(box) < boxHeader+8 {
+		return
+	}
+	count := binary.BigEndian.Uint32(box[boxHeader+4 : boxHeader+8])
+	pos := boxHeader + 8
+	for i := uint32(0); i < count && pos+4 <= len(box); i++ {
+		off := int64(binary.BigEndian.Uint32(box[pos:pos+4])) - int64(delta)
+		if off < 0 {
+			off = 0
+		}
+		binary.BigEndian.PutUint32(box[pos:pos+4], uint32(off))
+		pos += 4
+	}
+}
+
+// patchCo64 adjusts a "co64" box's 64-bit chunk offset table in place.
+func patchCo64(box []byte, boxHeader, delta int) {
+	if len(box) < boxHeader+8 {
+		return
+	}
+	count := binary.BigEndian.Uint32(box[boxHeader+4 : boxHeader+8])
+	pos := boxHeader + 8
+	for i := uint32(0); i < count && pos+8 <= len(box); i++ {
+		off := int64(binary.BigEndian.Uint64(box[pos:pos+8])) - int64(delta)
+		if off < 0 {
+			off = 0
+		}
+		binary.BigEndian.PutUint64(box[pos:pos+8], uint64(off))
+		pos += 8
+	}
+}
+
+// patchItemLocations finds a top-level "meta" box's "iloc" child, if any,
+// and adjusts every file-based item extent offset it records by delta, so a
+// HEIF/AVIF item pointing at bytes after a removed box (typically inside
+// mdat) keeps pointing at the right place.
+func patchItemLocations(data []byte, delta int) {
+	for _, b := range walkTopBoxes(data) {
+		if b.typ != "meta" {
+			continue
+		}
+		// meta is itself a FullBox: a 4-byte version+flags field precedes
+		// its child box sequence.
+		payload := data[b.start+b.header : b.start+b.size]
+		if len(payload) < 4 {
+			continue
+		}
+		for _, c := range walkTopBoxes(payload[4:]) {
+			if c.typ == "iloc" {
+				patchIloc(payload[4+c.start:4+c.start+c.size], c.header, delta)
+			}
+		}
+	}
+}
+
+// patchIloc adjusts an ItemLocationBox's (ISO/IEC 14496-12) file-based
+// extent and base offsets in place. construction_method 1 (idat-relative)
+// and 2 (item-indirect) offsets aren't byte positions in the file and are
+// left untouched; only construction_method 0 (file-relative, the default
+// for version 0) needs shifting.
+func patchIloc(box []byte, boxHeader, delta int) {
+	body := box[boxHeader:]
+	if len(body) < 4 {
+		return
+	}
+	version := body[0]
+	pos := 4 // skip version + flags
+
+	if len(body) < pos+1 {
+		return
+	}
+	offsetSize := int(body[pos] >> 4)
+	lengthSize := int(body[pos] & 0x0F)
+	pos++
+
+	// The base_offset_size/index_size byte is present in every version; the
+	// low nibble is only meaningful (as index_size) for version 1/2 and is
+	// reserved (0) otherwise.
+	if len(body) < pos+1 {
+		return
+	}
+	baseOffsetSize := int(body[pos] >> 4)
+	indexSize := int(body[pos] & 0x0F)
+	pos++
+
+	idSize := 2
+	itemCountSize := 2
+	if version == 2 {
+		idSize = 4
+		itemCountSize = 4
+	}
+	if len(body) < pos+itemCountSize {
+		return
+	}
+	var itemCount int
+	if itemCountSize == 2 {
+		itemCount = int(binary.BigEndian.Uint16(body[pos : pos+2]))
+	} else {
+		itemCount = int(binary.BigEndian.Uint32(body[pos : pos+4]))
+	}
+	pos += itemCountSize
+
+	for i := 0; i < itemCount; i++ {
+		if len(body) < pos+idSize {
+			return
+		}
+		pos += idSize // item_ID
+
+		constructionMethod := 0
+		if version == 1 || version == 2 {
+			if len(body) < pos+2 {
+				return
+			}
+			constructionMethod = int(binary.BigEndian.Uint16(body[pos:pos+2]) & 0x0F)
+			pos += 2
+		}
+
+		if len(body) < pos+2 {
+			return
+		}
+		pos += 2 // data_reference_index
+
+		if len(body) < pos+baseOffsetSize {
+			return
+		}
+		if constructionMethod == 0 {
+			adjustOffset(body[pos:pos+baseOffsetSize], delta)
+		}
+		pos += baseOffsetSize
+
+		if len(body) < pos+2 {
+			return
+		}
+		extentCount := int(binary.BigEndian.Uint16(body[pos : pos+2]))
+		pos += 2
+
+		for e := 0; e < extentCount; e++ {
+			if version == 1 || version == 2 {
+				if len(body) < pos+indexSize {
+					return
+				}
+				pos += indexSize
+			}
+			if len(body) < pos+offsetSize {
+				return
+			}
+			if constructionMethod == 0 {
+				adjustOffset(body[pos:pos+offsetSize], delta)
+			}
+			pos += offsetSize
+
+			if len(body) < pos+lengthSize {
+				return
+			}
+			pos += lengthSize
+		}
+	}
+}
+
+// adjustOffset subtracts delta from the big-endian integer stored in buf (4
+// or 8 bytes), clamping at zero so a malformed or already-adjusted offset
+// doesn't wrap around.
+func adjustOffset(buf []byte, delta int) {
+	switch len(buf) {
+	case 4:
+		off := int64(binary.BigEndian.Uint32(buf)) - int64(delta)
+		if off < 0 {
+			off = 0
+		}
+		binary.BigEndian.PutUint32(buf, uint32(off))
+	case 8:
+		off := int64(binary.BigEndian.Uint64(buf)) - int64(delta)
+		if off < 0 {
+			off = 0
+		}
+		binary.BigEndian.PutUint64(buf, uint64(off))
+	}
+}
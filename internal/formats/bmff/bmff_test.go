@@ -0,0 +1,123 @@
+package bmff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// appendBox appends a single BMFF box (32-bit size + 4-byte type + payload)
+// to buf and returns the result.
+func appendBox(buf []byte, typ string, payload []byte) []byte {
+	size := uint32(8 + len(payload))
+	head := make([]byte, 8)
+	binary.BigEndian.PutUint32(head[0:4], size)
+	copy(head[4:8], typ)
+	buf = append(buf, head...)
+	return append(buf, payload...)
+}
+
+// ilocV0 builds a minimal version-0 iloc box body (without its own
+// size+type header) describing a single item with one file-based extent.
+func ilocV0(itemID uint16, offset, length uint32) []byte {
+	body := []byte{0, 0, 0, 0} // version 0, flags 0
+	body = append(body, 0x44)  // offset_size=4, length_size=4
+	body = append(body, 0x00)  // base_offset_size=0, reserved=0
+	itemCount := make([]byte, 2)
+	binary.BigEndian.PutUint16(itemCount, 1)
+	body = append(body, itemCount...)
+
+	item := make([]byte, 2)
+	binary.BigEndian.PutUint16(item, itemID)
+	body = append(body, item...)      // item_ID
+	body = append(body, 0x00, 0x00)   // data_reference_index
+	body = append(body, 0x00, 0x01)   // extent_count = 1
+	off := make([]byte, 4)
+	binary.BigEndian.PutUint32(off, offset)
+	body = append(body, off...)
+	ln := make([]byte, 4)
+	binary.BigEndian.PutUint32(ln, length)
+	body = append(body, ln...)
+	return body
+}
+
+// buildHEIC assembles ftyp, a C2PA "uuid" box, a meta box (with a single
+// iloc item pointing at the mdat payload) and mdat, in that order, so the
+// item's extent offset is an absolute file offset into mdat.
+func buildHEIC(mdatPayload []byte) (data []byte, itemOffsetInMdat uint32) {
+	var buf []byte
+	buf = appendBox(buf, "ftyp", []byte("heic\x00\x00\x00\x00heicmif1"))
+
+	uuidPayload := append([]byte{}, C2PAUUID[:]...)
+	uuidPayload = append(uuidPayload, []byte("c2pa manifest payload")...)
+	buf = appendBox(buf, "uuid", uuidPayload)
+
+	// Build meta with a placeholder iloc extent offset (0), then patch it
+	// once mdat's payload position is known, so the synthetic file is
+	// internally consistent before the uuid box is removed by Remove().
+	metaPayload := []byte{0, 0, 0, 0} // meta FullBox version+flags
+	ilocBody := ilocV0(1, 0, uint32(len(mdatPayload)))
+	metaPayload = appendBox(metaPayload, "iloc", ilocBody)
+	buf = appendBox(buf, "meta", metaPayload)
+
+	mdatHeaderStart := len(buf)
+	buf = appendBox(buf, "mdat", mdatPayload)
+	mdatPayloadStart := uint32(mdatHeaderStart + 8)
+
+	patchSyntheticIlocOffset(buf, mdatPayloadStart)
+	return buf, mdatPayloadStart
+}
+
+// patchSyntheticIlocOffset finds the iloc box built by buildHEIC and writes
+// the real extent_offset now that mdat's position is known.
+func patchSyntheticIlocOffset(data []byte, value uint32) {
+	idx := bytes.Index(data, []byte("iloc"))
+	if idx < 0 {
+		panic("test setup: iloc box not found")
+	}
+	// Layout after "iloc": version/flags(4) + sizes(2) + item_count(2) +
+	// item_ID(2) + data_reference_index(2) + extent_count(2) = 14 bytes,
+	// then the 4-byte extent_offset.
+	offsetPos := idx + 4 + 14
+	binary.BigEndian.PutUint32(data[offsetPos:offsetPos+4], value)
+}
+
+func TestRemovePatchesIlocOffsetsForHEIF(t *testing.T) {
+	mdatPayload := bytes.Repeat([]byte{0xAB}, 32)
+	data, originalItemOffset := buildHEIC(mdatPayload)
+
+	if !Check(data) {
+		t.Fatal("Check() = false, want true for a HEIC file carrying a C2PA uuid box")
+	}
+
+	cleaned, err := Remove(data)
+	if err != nil {
+		t.Fatalf("Remove() error: %v", err)
+	}
+	if Check(cleaned) {
+		t.Fatal("Remove() left the C2PA uuid box in place")
+	}
+
+	idx := bytes.Index(cleaned, []byte("iloc"))
+	if idx < 0 {
+		t.Fatal("Remove() dropped the iloc box entirely")
+	}
+	offsetPos := idx + 4 + 14
+	gotOffset := binary.BigEndian.Uint32(cleaned[offsetPos : offsetPos+4])
+
+	// bytes.Index finds the 4-byte type string, which sits 4 bytes into the
+	// box (after its 4-byte size field), so the payload starts 4 bytes
+	// past that, not 8.
+	mdatIdx := bytes.Index(cleaned, []byte("mdat"))
+	if mdatIdx < 0 {
+		t.Fatal("Remove() dropped the mdat box entirely")
+	}
+	wantOffset := uint32(mdatIdx + 4)
+
+	if gotOffset != wantOffset {
+		t.Fatalf("iloc extent_offset = %d, want %d (original was %d); item extent no longer points at its mdat bytes", gotOffset, wantOffset, originalItemOffset)
+	}
+	if !bytes.Equal(cleaned[gotOffset:gotOffset+uint32(len(mdatPayload))], mdatPayload) {
+		t.Fatal("patched iloc offset does not point at the original mdat payload")
+	}
+}
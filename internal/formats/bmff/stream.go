@@ -0,0 +1,108 @@
+package bmff
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/ngmisl/C2PAremover/internal/jumbf"
+	"github.com/ngmisl/C2PAremover/internal/segment"
+)
+
+// streamBufSize bounds the scratch bufio.Reader used by DetectStream; box
+// headers are at most 16 bytes, well under it.
+const streamBufSize = 64 * 1024
+
+// DetectStream reports whether the BMFF-based (HEIF/AVIF/MP4/MOV) file read
+// from r carries a top-level C2PA "uuid" or "jumb" box, without buffering
+// opaque sample data into memory: every top-level box's header is read to
+// learn its size and type, and only a "uuid" or "jumb" box's payload - never
+// mdat's, which is where the bulk of a real file's bytes live - is read for
+// inspection. Every other box is skipped by discarding its payload as it's
+// read, never holding more than one box's payload at a time.
+func DetectStream(r io.Reader) (bool, []segment.Info, error) {
+	br := bufio.NewReaderSize(r, streamBufSize)
+	pos := 0
+	var infos []segment.Info
+
+	for {
+		head := make([]byte, 8)
+		if _, err := io.ReadFull(br, head); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return len(infos) > 0, infos, fmt.Errorf("bmff: %w", err)
+		}
+		start := pos
+		typ := string(head[4:8])
+		size64 := uint64(binary.BigEndian.Uint32(head[0:4]))
+		headerLen := 8
+		pos += 8
+
+		switch size64 {
+		case 1:
+			ext := make([]byte, 8)
+			if _, err := io.ReadFull(br, ext); err != nil {
+				return len(infos) > 0, infos, fmt.Errorf("bmff: %w", err)
+			}
+			pos += 8
+			size64 = binary.BigEndian.Uint64(ext)
+			headerLen = 16
+		case 0:
+			// Box extends to EOF: nothing after it to scan either way.
+			if _, err := io.Copy(io.Discard, br); err != nil {
+				return len(infos) > 0, infos, fmt.Errorf("bmff: %w", err)
+			}
+			return len(infos) > 0, infos, nil
+		}
+
+		if size64 < uint64(headerLen) {
+			return len(infos) > 0, infos, fmt.Errorf("bmff: box %q has invalid size", typ)
+		}
+		payloadLen := int64(size64) - int64(headerLen)
+
+		if typ != "uuid" && typ != "jumb" {
+			if _, err := io.CopyN(io.Discard, br, payloadLen); err != nil {
+				return len(infos) > 0, infos, fmt.Errorf("bmff: %w", err)
+			}
+			pos += int(payloadLen)
+			continue
+		}
+
+		// A box's declared size is untrusted input - read only as far as
+		// the stream actually goes rather than pre-allocating payloadLen
+		// up front, or a crafted box claiming a multi-terabyte size would
+		// crash the process before the truncation check below ever runs.
+		payload, err := io.ReadAll(io.LimitReader(br, payloadLen))
+		if err != nil {
+			return len(infos) > 0, infos, fmt.Errorf("bmff: %w", err)
+		}
+		if int64(len(payload)) != payloadLen {
+			return len(infos) > 0, infos, fmt.Errorf("bmff: box %q payload truncated", typ)
+		}
+		pos += len(payload)
+
+		if !isC2PAPayload(typ, payload) {
+			continue
+		}
+		info := segment.Info{Kind: typ, Offset: start, Length: int(size64)}
+		switch typ {
+		case "uuid":
+			info.UUID = fmt.Sprintf("%x", C2PAUUID[:])
+		case "jumb":
+			header := make([]byte, 8)
+			binary.BigEndian.PutUint32(header[0:4], uint32(8+len(payload)))
+			copy(header[4:8], "jumb")
+			if parsed, err := jumbf.ParseBox(append(header, payload...)); err == nil {
+				if desc, ok := parsed.Description(); ok {
+					if uuid, ok := desc.UUID(); ok {
+						info.UUID = fmt.Sprintf("%x", uuid[:])
+					}
+				}
+			}
+		}
+		infos = append(infos, info)
+	}
+	return len(infos) > 0, infos, nil
+}
@@ -0,0 +1,62 @@
+package bmff
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetectStreamMatchesCheck(t *testing.T) {
+	mdatPayload := bytes.Repeat([]byte{0xAB}, 1<<20) // large enough to matter if mdat were buffered whole
+	data, _ := buildHEIC(mdatPayload)
+
+	want := Check(data)
+	has, infos, err := DetectStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DetectStream() error: %v", err)
+	}
+	if has != want {
+		t.Fatalf("DetectStream() = %v, want %v (Check())", has, want)
+	}
+	if len(infos) != 1 || infos[0].Kind != "uuid" {
+		t.Fatalf("DetectStream() infos = %+v, want a single uuid entry", infos)
+	}
+}
+
+// TestDetectStreamRejectsOversizedBoxWithoutOOM reproduces a crafted file
+// whose "uuid" box claims a multi-terabyte largesize far beyond the bytes
+// actually available. DetectStream must report a truncation error instead
+// of allocating the claimed size (which would OOM-crash the process - not
+// recoverable via recover() - well before any truncation check could run).
+func TestDetectStreamRejectsOversizedBoxWithoutOOM(t *testing.T) {
+	var buf []byte
+	buf = appendBox(buf, "ftyp", []byte("heic\x00\x00\x00\x00heicmif1"))
+
+	const hugeSize = uint64(1) << 40 // 1 TiB
+	head := make([]byte, 16)
+	head[3] = 1 // LBox = 1: size is in the following 8-byte XLBox field
+	copy(head[4:8], "uuid")
+	for i := 0; i < 8; i++ {
+		head[8+i] = byte(hugeSize >> (56 - 8*i))
+	}
+	buf = append(buf, head...)
+	buf = append(buf, bytes.Repeat([]byte{0xAA}, 16)...) // far short of the declared size
+
+	has, infos, err := DetectStream(bytes.NewReader(buf))
+	if err == nil {
+		t.Fatalf("DetectStream() = (%v, %+v, nil), want a truncation error for a box claiming %d bytes with only 16 available", has, infos, hugeSize)
+	}
+}
+
+func TestDetectStreamIgnoresPlainFile(t *testing.T) {
+	var buf []byte
+	buf = appendBox(buf, "ftyp", []byte("heic\x00\x00\x00\x00heicmif1"))
+	buf = appendBox(buf, "mdat", bytes.Repeat([]byte{0xCD}, 64))
+
+	has, infos, err := DetectStream(bytes.NewReader(buf))
+	if err != nil {
+		t.Fatalf("DetectStream() error: %v", err)
+	}
+	if has || len(infos) != 0 {
+		t.Fatalf("DetectStream() has=%v infos=%+v, want nothing detected in a plain file", has, infos)
+	}
+}
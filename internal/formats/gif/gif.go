@@ -0,0 +1,91 @@
+// Package gif provides minimal C2PA detection/removal for GIF images,
+// which carry C2PA manifests in an Application Extension block identified
+// by the "C2PA" application identifier.
+package gif
+
+import (
+	"bytes"
+
+	"github.com/ngmisl/C2PAremover/internal/segment"
+)
+
+// findC2PABlock scans a GIF's block stream (after the header, logical
+// screen descriptor and optional global color table) for a C2PA
+// Application Extension, returning its [start, end) byte range.
+func findC2PABlock(data []byte) (start, end int, found bool) {
+	if len(data) < 13 || !(bytes.HasPrefix(data, []byte("GIF87a")) || bytes.HasPrefix(data, []byte("GIF89a"))) {
+		return 0, 0, false
+	}
+
+	packed := data[10]
+	pos := 13
+	if packed&0x80 != 0 { // global color table present
+		pos += 3 * (2 << (packed & 0x07))
+	}
+
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // Extension Introducer
+			if pos+1 >= len(data) {
+				return 0, 0, false
+			}
+			label := data[pos+1]
+			blockStart := pos
+			sub := pos + 2
+			for sub < len(data) {
+				size := int(data[sub])
+				sub++
+				if size == 0 {
+					break
+				}
+				sub += size
+			}
+			if sub > len(data) {
+				return 0, 0, false
+			}
+			if label == 0xFF && sub-blockStart >= 2+1+8 {
+				appID := data[blockStart+3 : blockStart+3+8]
+				if bytes.Equal(appID, []byte("C2PA")) || bytes.HasPrefix(appID, []byte("C2PA")) {
+					return blockStart, sub, true
+				}
+			}
+			pos = sub
+		default:
+			// Image Descriptor (0x2C), Trailer (0x3B), or anything else
+			// means we've reached image data - no more metadata blocks.
+			return 0, 0, false
+		}
+	}
+	return 0, 0, false
+}
+
+// Check reports whether data (a GIF image) carries a C2PA Application
+// Extension block.
+func Check(data []byte) bool {
+	_, _, found := findC2PABlock(data)
+	return found
+}
+
+// Inspect reports the C2PA Application Extension block in a GIF image, if
+// any.
+func Inspect(data []byte) []segment.Info {
+	start, end, found := findC2PABlock(data)
+	if !found {
+		return nil
+	}
+	return []segment.Info{{Kind: "application-extension", Offset: start, Length: end - start}}
+}
+
+// Remove strips the C2PA Application Extension block from a GIF image,
+// copying everything else through byte-for-byte.
+func Remove(data []byte) ([]byte, error) {
+	start, end, found := findC2PABlock(data)
+	if !found {
+		return data, nil
+	}
+
+	result := make([]byte, 0, len(data)-(end-start))
+	result = append(result, data[:start]...)
+	result = append(result, data[end:]...)
+	return result, nil
+}
@@ -0,0 +1,385 @@
+// Package jpeg provides C2PA detection/removal for JPEG images, which
+// carry C2PA manifests in APP11 JUMBF boxes and/or XMP metadata in APP1.
+package jpeg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ngmisl/C2PAremover/internal/jumbf"
+	"github.com/ngmisl/C2PAremover/internal/segment"
+)
+
+// C2PA XMP markers looked for inside APP1 segments.
+const (
+	c2paNamespace   = "http://c2pa.org/"
+	c2paManifestTag = "c2pa:manifest"
+	c2paClaimTag    = "c2pa:claim"
+)
+
+var c2paXMPRegex = regexp.MustCompile(`(?i)c2pa|contentauthenticity|contentcredentials|cai`)
+
+// jpegSegment is one marker segment of a JPEG, as located by parseSegments.
+type jpegSegment struct {
+	markerType byte
+	start, end int // [start, end) spans the 0xFF marker through the segment's payload
+	payload    []byte
+}
+
+// resync scans forward from pos looking for the next byte pair that looks
+// like a real marker (0xFF followed by something other than a stuffing
+// 0x00 or a fill 0xFF), so a malformed segment doesn't abort parsing
+// altogether. It returns len(data) if no candidate is found.
+func resync(data []byte, pos int) int {
+	for i := pos; i < len(data)-1; i++ {
+		if data[i] == 0xFF && data[i+1] != 0x00 && data[i+1] != 0xFF {
+			return i
+		}
+	}
+	return len(data)
+}
+
+// parseSegments walks data's marker segments from just after the SOI,
+// stopping at SOS (whose byte range is returned but not descended into).
+// It tolerates the fill bytes (runs of 0xFF before the real marker code)
+// and malformed length fields real-world encoders occasionally produce:
+// on an invalid length it resyncs to the next plausible marker instead of
+// giving up, recording the skipped span as an opaque, untyped segment so
+// Remove still reproduces it byte-for-byte. It's the shared pre-pass Check
+// and Remove both build on, so a multi-segment JUMBF manifest only needs
+// to be reassembled once.
+func parseSegments(data []byte) (segs []jpegSegment, foundSOS bool) {
+	pos := 2 // skip SOI marker
+	for pos < len(data)-1 {
+		if data[pos] != 0xFF {
+			pos++
+			continue
+		}
+		markerType := data[pos+1]
+		if markerType == 0xFF { // fill byte: the real marker code follows
+			pos++
+			continue
+		}
+
+		if markerType == 0xDA { // SOS: no more metadata segments follow
+			segs = append(segs, jpegSegment{markerType: markerType, start: pos, end: len(data)})
+			return segs, true
+		}
+		if markerType == 0xD9 { // EOI
+			segs = append(segs, jpegSegment{markerType: markerType, start: pos, end: pos + 2})
+			return segs, false
+		}
+		if (markerType >= 0xD0 && markerType <= 0xD7) || markerType == 0x01 {
+			// RST markers and TEM have no length field.
+			segs = append(segs, jpegSegment{markerType: markerType, start: pos, end: pos + 2})
+			pos += 2
+			continue
+		}
+
+		length := -1
+		if pos+4 <= len(data) {
+			length = int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		}
+		if length < 2 || pos+2+length > len(data) {
+			resumePos := resync(data, pos+2)
+			segs = append(segs, jpegSegment{markerType: 0, start: pos, end: resumePos})
+			pos = resumePos
+			continue
+		}
+
+		segs = append(segs, jpegSegment{
+			markerType: markerType,
+			start:      pos,
+			end:        pos + 2 + length,
+			payload:    data[pos+4 : pos+2+length],
+		})
+		pos += 2 + length
+	}
+	return segs, false
+}
+
+// jumbfGroup is a logical JUMBF box reassembled from one or more APP11
+// segments that share the same En (box instance number), ordered by Z
+// (packet sequence number), since a manifest too large for one ~64 KiB
+// JPEG segment is split across several.
+type jumbfGroup struct {
+	En       uint16
+	Segments []int // indices into the segment slice this group spans
+	Payload  []byte
+	TopType  string
+	UUID     [16]byte
+}
+
+// isC2PA reports whether the group's reassembled JUMBF box is a C2PA
+// manifest.
+func (g jumbfGroup) isC2PA() bool {
+	box, err := jumbf.ParseBox(g.Payload)
+	return err == nil && box.IsC2PA()
+}
+
+// groupJUMBF reassembles every APP11 segment in segs into jumbfGroups by
+// (CI, En), sorted by Z, so a manifest split across many segments is
+// inspected and removed as one unit instead of segment-by-segment.
+func groupJUMBF(segs []jpegSegment) []jumbfGroup {
+	type part struct {
+		idx     int
+		z       uint32
+		payload []byte
+	}
+	byEn := map[uint16][]part{}
+	for i, s := range segs {
+		if s.markerType != 0xEB { // APP11
+			continue
+		}
+		if len(s.payload) < 8 || string(s.payload[0:2]) != "JP" {
+			continue
+		}
+		en := binary.BigEndian.Uint16(s.payload[2:4])
+		z := binary.BigEndian.Uint32(s.payload[4:8])
+		byEn[en] = append(byEn[en], part{idx: i, z: z, payload: s.payload[8:]})
+	}
+
+	ens := make([]uint16, 0, len(byEn))
+	for en := range byEn {
+		ens = append(ens, en)
+	}
+	sort.Slice(ens, func(a, b int) bool { return ens[a] < ens[b] })
+
+	groups := make([]jumbfGroup, 0, len(ens))
+	for _, en := range ens {
+		parts := byEn[en]
+		sort.Slice(parts, func(a, b int) bool { return parts[a].z < parts[b].z })
+
+		g := jumbfGroup{En: en}
+		for _, p := range parts {
+			g.Segments = append(g.Segments, p.idx)
+			g.Payload = append(g.Payload, p.payload...)
+		}
+		if box, err := jumbf.ParseBox(g.Payload); err == nil {
+			g.TopType = box.TBox
+			if desc, ok := box.Description(); ok {
+				if uuid, ok := desc.UUID(); ok {
+					g.UUID = uuid
+				}
+			}
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// xmpURIPrefix is the null-terminated namespace URI every APP1 XMP
+// segment's payload begins with, ahead of the actual <x:xmpmeta> packet.
+var xmpURIPrefix = []byte("http://ns.adobe.com/xap/1.0/\x00")
+
+// xmpHasC2PA reports whether an APP1 XMP segment's payload contains C2PA
+// content.
+func xmpHasC2PA(segmentData []byte) bool {
+	if !bytes.HasPrefix(segmentData, []byte("http://ns.adobe.com/xap/1.0/")) {
+		return false
+	}
+	xmpString := string(segmentData)
+	if strings.Contains(xmpString, c2paNamespace) ||
+		strings.Contains(xmpString, c2paManifestTag) ||
+		strings.Contains(xmpString, c2paClaimTag) {
+		return true
+	}
+	return c2paXMPRegex.MatchString(xmpString)
+}
+
+// Regexes used to surgically remove only the C2PA-bearing parts of an XMP
+// packet, leaving unrelated properties (copyright, capture time, rating,
+// ...) that happen to share the same packet intact. This is a best-effort
+// textual edit, not a full RDF parse, in keeping with the regex-based XMP
+// matching already used by xmpHasC2PA.
+var (
+	c2paElementRegex  = regexp.MustCompile(`(?s)<c2pa:[A-Za-z0-9_]+[^>]*?/>|<c2pa:[A-Za-z0-9_]+[^>]*?>.*?</c2pa:[A-Za-z0-9_]+>`)
+	c2paAttrRegex     = regexp.MustCompile(`\s+(?:xmlns:c2pa|c2pa:[A-Za-z0-9_]+|Iptc4xmpExt:DigitalSourceType)="[^"]*"`)
+	xmpWrapperRegex   = regexp.MustCompile(`(?s)<\?xpacket[^>]*\?>|</?x:xmpmeta[^>]*>|</?rdf:RDF[^>]*>`)
+	emptyRDFDescRegex = regexp.MustCompile(`(?s)<rdf:Description(?:\s+xmlns:[A-Za-z0-9]+="[^"]*")*\s*(?:/>|>\s*</rdf:Description>)`)
+)
+
+// stripC2PAFromXMP removes c2pa:* elements, the xmlns:c2pa namespace
+// declaration, and the C2PA-paired Iptc4xmpExt:DigitalSourceType
+// attribute from an XMP packet. It reports the cleaned bytes and whether
+// the removal was clean; if c2pa content remains afterward (e.g. a layout
+// this regex pass doesn't target), the caller should fall back to
+// dropping the whole segment rather than keep a half-cleaned one.
+func stripC2PAFromXMP(xmp []byte) (cleaned []byte, ok bool) {
+	s := c2paElementRegex.ReplaceAll(xmp, nil)
+	s = c2paAttrRegex.ReplaceAll(s, nil)
+	if c2paXMPRegex.Match(s) {
+		return xmp, false
+	}
+	return s, true
+}
+
+// isXMPEmpty reports whether an XMP packet, once its wrapper tags are
+// stripped away, has nothing left but empty rdf:Description elements -
+// i.e. removing C2PA content left no other metadata worth keeping.
+func isXMPEmpty(xmp []byte) bool {
+	s := xmpWrapperRegex.ReplaceAll(xmp, nil)
+	s = emptyRDFDescRegex.ReplaceAll(s, nil)
+	return len(bytes.TrimSpace(s)) == 0
+}
+
+// xmpC2PAPropertyRegex matches the individual C2PA element/attribute names
+// inside an XMP packet, for reporting in a Segment's XMPProperties.
+var xmpC2PAPropertyRegex = regexp.MustCompile(`xmlns:c2pa|c2pa:[A-Za-z0-9_]+|Iptc4xmpExt:DigitalSourceType`)
+
+// xmpC2PAProperties returns the distinct C2PA element/attribute names found
+// in an XMP packet, in first-seen order.
+func xmpC2PAProperties(xmp []byte) []string {
+	var props []string
+	seen := make(map[string]bool)
+	for _, m := range xmpC2PAPropertyRegex.FindAllString(string(xmp), -1) {
+		if !seen[m] {
+			seen[m] = true
+			props = append(props, m)
+		}
+	}
+	return props
+}
+
+// Inspect reports every C2PA-carrying region of a JPEG: each reassembled
+// APP11 JUMBF group and each APP1 XMP packet with C2PA content.
+func Inspect(data []byte) []segment.Info {
+	segs, _ := parseSegments(data)
+
+	var infos []segment.Info
+	for _, g := range groupJUMBF(segs) {
+		if !g.isC2PA() {
+			continue
+		}
+		// g.Segments is in Z order, which need not be file order.
+		start, end := segs[g.Segments[0]].start, segs[g.Segments[0]].end
+		for _, idx := range g.Segments[1:] {
+			start = min(start, segs[idx].start)
+			end = max(end, segs[idx].end)
+		}
+		info := segment.Info{Kind: "jumbf", Offset: start, Length: end - start}
+		if g.UUID != ([16]byte{}) {
+			info.UUID = fmt.Sprintf("%x", g.UUID[:])
+		}
+		infos = append(infos, info)
+	}
+	for _, s := range segs {
+		if s.markerType == 0xE1 && xmpHasC2PA(s.payload) {
+			infos = append(infos, segment.Info{
+				Kind:          "xmp",
+				Offset:        s.start,
+				Length:        s.end - s.start,
+				XMPProperties: xmpC2PAProperties(s.payload),
+			})
+		}
+	}
+	return infos
+}
+
+// Check reports whether data (a JPEG image) carries C2PA metadata in
+// either a (possibly multi-segment) APP11 JUMBF box or an APP1 XMP packet.
+func Check(data []byte) bool {
+	segs, _ := parseSegments(data)
+
+	for _, g := range groupJUMBF(segs) {
+		if g.isC2PA() {
+			return true
+		}
+	}
+	for _, s := range segs {
+		if s.markerType == 0xE1 && xmpHasC2PA(s.payload) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove strips C2PA-carrying APP1/APP11 content from a JPEG, preserving
+// every other segment (APP0/JFIF, ICC profiles, EXIF, non-C2PA XMP
+// properties) byte-for-byte. It's equivalent to RemoveMode(data, true).
+func Remove(data []byte) ([]byte, error) {
+	return RemoveMode(data, true)
+}
+
+// RemoveMode strips C2PA-carrying APP1 segments and APP11 JUMBF groups
+// from a JPEG, copying every other segment through byte-for-byte - no
+// pixel decode or requantization. A manifest split across several APP11
+// segments is dropped as a whole group, not segment-by-segment.
+//
+// When preserveMetadata is true, an APP1 XMP packet that mixes C2PA
+// properties with other metadata (copyright, capture time, rating, ...)
+// has only the C2PA properties surgically removed, and the segment is
+// dropped entirely only if that leaves it empty. When false, any XMP
+// packet containing C2PA content is dropped wholesale, which is simpler
+// but also discards whatever else that packet carried.
+func RemoveMode(data []byte, preserveMetadata bool) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("jpeg: not a valid JPEG file")
+	}
+
+	segs, foundSOS := parseSegments(data)
+
+	dropC2PA := make(map[int]bool)
+	for _, g := range groupJUMBF(segs) {
+		if g.isC2PA() {
+			for _, idx := range g.Segments {
+				dropC2PA[idx] = true
+			}
+		}
+	}
+
+	result := []byte{0xFF, 0xD8} // SOI
+	for i, s := range segs {
+		if dropC2PA[i] {
+			continue
+		}
+		if s.markerType == 0xE1 && xmpHasC2PA(s.payload) {
+			if edited, ok := editXMPSegment(s.payload, preserveMetadata); ok {
+				result = append(result, edited...)
+				continue
+			}
+			continue
+		}
+		result = append(result, data[s.start:s.end]...)
+	}
+
+	if !foundSOS && !bytes.HasSuffix(result, []byte{0xFF, 0xD9}) {
+		result = append(result, 0xFF, 0xD9) // ensure the output is a valid JPEG
+	}
+	return result, nil
+}
+
+// editXMPSegment tries to rebuild a C2PA-bearing APP1 XMP segment with
+// only its C2PA content removed. payload is the segment's raw bytes as
+// returned by parseSegments (i.e. everything after the 2-byte length
+// field). It reports ok == false when the whole segment should be
+// dropped instead: preserveMetadata is off, the packet couldn't be
+// cleanly edited, or the clean left nothing else behind.
+func editXMPSegment(payload []byte, preserveMetadata bool) (segmentBytes []byte, ok bool) {
+	if !preserveMetadata || !bytes.HasPrefix(payload, xmpURIPrefix) {
+		return nil, false
+	}
+
+	xmp := payload[len(xmpURIPrefix):]
+	cleaned, cleanedOK := stripC2PAFromXMP(xmp)
+	if !cleanedOK || isXMPEmpty(cleaned) {
+		return nil, false
+	}
+
+	newPayload := append(append([]byte{}, xmpURIPrefix...), cleaned...)
+	length := 2 + len(newPayload)
+	if length > 0xFFFF {
+		return nil, false
+	}
+
+	out := make([]byte, 0, 4+len(newPayload))
+	out = append(out, 0xFF, 0xE1)
+	out = append(out, byte(length>>8), byte(length))
+	out = append(out, newPayload...)
+
+	return out, true
+}
@@ -0,0 +1,359 @@
+package jpeg
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ngmisl/C2PAremover/internal/jumbf"
+	"github.com/ngmisl/C2PAremover/internal/segment"
+)
+
+// maxMarkerSegment bounds the scratch bufio.Reader used by the streaming
+// functions below; it's sized for the largest marker segment a JPEG can
+// carry (the 16-bit length field caps a segment at 64 KiB).
+const maxMarkerSegment = 64 * 1024
+
+// posReader wraps a *bufio.Reader with a running count of bytes delivered,
+// so the streaming marker walk below can report accurate segment offsets
+// without ever buffering the bytes it doesn't need (in particular the
+// compressed scan data after SOS, which dwarfs the header segments).
+type posReader struct {
+	br  *bufio.Reader
+	pos int
+}
+
+func (p *posReader) ReadByte() (byte, error) {
+	b, err := p.br.ReadByte()
+	if err == nil {
+		p.pos++
+	}
+	return b, err
+}
+
+func (p *posReader) Read(buf []byte) (int, error) {
+	n, err := p.br.Read(buf)
+	p.pos += n
+	return n, err
+}
+
+// readMarker scans forward from p for the next genuine marker byte (0xFF
+// followed by something other than a stuffed 0x00 or a fill 0xFF) - the
+// same tolerance parseSegments applies to an in-memory buffer - and
+// returns its type and the offset of the 0xFF byte immediately before it.
+func readMarker(p *posReader) (markerType byte, start int, err error) {
+	for {
+		b, err := p.ReadByte()
+		if err != nil {
+			return 0, 0, err
+		}
+		if b != 0xFF {
+			continue
+		}
+		start = p.pos - 1
+		for {
+			b2, err := p.ReadByte()
+			if err != nil {
+				return 0, 0, err
+			}
+			if b2 == 0xFF {
+				start = p.pos - 1
+				continue
+			}
+			if b2 == 0x00 {
+				break // stuffed byte; not a marker, resume the outer scan
+			}
+			return b2, start, nil
+		}
+	}
+}
+
+// jumbfPart is one APP11 segment's contribution to a reassembled JUMBF
+// group, identified by (En, Z) the same way groupJUMBF reassembles an
+// in-memory buffer.
+type jumbfPart struct {
+	z       uint32
+	payload []byte
+}
+
+// reassembleJUMBF concatenates an En group's parts in Z order and parses
+// the result as a single JUMBF box.
+func reassembleJUMBF(parts []jumbfPart) (jumbf.Box, error) {
+	sorted := append([]jumbfPart{}, parts...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a].z < sorted[b].z })
+	var payload []byte
+	for _, p := range sorted {
+		payload = append(payload, p.payload...)
+	}
+	return jumbf.ParseBox(payload)
+}
+
+// DetectStream reports whether the JPEG read from r carries C2PA metadata,
+// without reading its compressed scan data into memory: marker segments up
+// to SOS are read through a bounded scratch buffer (maxMarkerSegment) and
+// inspected, and the stream is abandoned as soon as SOS (or EOI, for a
+// header-only file) is reached, since no C2PA marker can appear after it.
+func DetectStream(r io.Reader) (bool, []segment.Info, error) {
+	p := &posReader{br: bufio.NewReaderSize(r, maxMarkerSegment)}
+
+	soi := make([]byte, 2)
+	if _, err := io.ReadFull(p, soi); err != nil {
+		return false, nil, fmt.Errorf("jpeg: reading SOI: %w", err)
+	}
+	if soi[0] != 0xFF || soi[1] != 0xD8 {
+		return false, nil, fmt.Errorf("jpeg: not a valid JPEG file")
+	}
+
+	byEn := map[uint16][]jumbfPart{}
+	enSpan := map[uint16][2]int{} // [start, end) covering every part seen for En
+	var infos []segment.Info
+
+	for {
+		markerType, start, err := readMarker(p)
+		if err != nil {
+			break // EOF (or a malformed tail) - nothing more to inspect
+		}
+		if markerType == 0xDA || markerType == 0xD9 { // SOS or EOI
+			break
+		}
+		if (markerType >= 0xD0 && markerType <= 0xD7) || markerType == 0x01 {
+			continue // RST markers and TEM have no length field
+		}
+
+		lenBuf := make([]byte, 2)
+		if _, err := io.ReadFull(p, lenBuf); err != nil {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(lenBuf))
+		if length < 2 {
+			break // malformed length; nothing reliable follows
+		}
+		payloadLen := length - 2
+
+		if markerType != 0xEB && markerType != 0xE1 {
+			if _, err := io.CopyN(io.Discard, p, int64(payloadLen)); err != nil {
+				break
+			}
+			continue
+		}
+
+		payload := make([]byte, payloadLen)
+		if _, err := io.ReadFull(p, payload); err != nil {
+			break
+		}
+
+		switch markerType {
+		case 0xEB: // APP11
+			if len(payload) < 8 || string(payload[0:2]) != "JP" {
+				continue
+			}
+			en := binary.BigEndian.Uint16(payload[2:4])
+			z := binary.BigEndian.Uint32(payload[4:8])
+			byEn[en] = append(byEn[en], jumbfPart{z: z, payload: payload[8:]})
+			span := enSpan[en]
+			if span[1] == 0 || start < span[0] {
+				span[0] = start
+			}
+			span[1] = p.pos
+			enSpan[en] = span
+		case 0xE1: // APP1
+			if xmpHasC2PA(payload) {
+				infos = append(infos, segment.Info{
+					Kind:          "xmp",
+					Offset:        start,
+					Length:        p.pos - start,
+					XMPProperties: xmpC2PAProperties(payload),
+				})
+			}
+		}
+	}
+
+	ens := make([]uint16, 0, len(byEn))
+	for en := range byEn {
+		ens = append(ens, en)
+	}
+	sort.Slice(ens, func(a, b int) bool { return ens[a] < ens[b] })
+
+	for _, en := range ens {
+		box, err := reassembleJUMBF(byEn[en])
+		if err != nil || !box.IsC2PA() {
+			continue
+		}
+		span := enSpan[en]
+		info := segment.Info{Kind: "jumbf", Offset: span[0], Length: span[1] - span[0]}
+		if desc, ok := box.Description(); ok {
+			if uuid, ok := desc.UUID(); ok {
+				info.UUID = fmt.Sprintf("%x", uuid[:])
+			}
+		}
+		infos = append(infos, info)
+	}
+	return len(infos) > 0, infos, nil
+}
+
+// RemoveStream strips C2PA-carrying APP1/APP11 content from the JPEG read
+// from src and writes the cleaned result to dst, without buffering its
+// compressed scan data: APP11 segments are held in memory until their
+// group's fate is known (so a manifest split across several segments is
+// kept or dropped as a whole), every other header segment is passed
+// through as it's read, and once SOS (or EOI) is reached the remainder of
+// src is copied straight to dst. It reports whether any C2PA content was
+// found and removed, plus the regions that were identified: each removed
+// APP11 segment and each C2PA-carrying XMP packet is its own entry, so a
+// manifest split across several APP11 segments yields one entry per
+// segment rather than DetectStream/Inspect's one per group.
+func RemoveStream(dst io.Writer, src io.Reader, preserveMetadata bool) (bool, []segment.Info, error) {
+	p := &posReader{br: bufio.NewReaderSize(src, maxMarkerSegment)}
+
+	soi := make([]byte, 2)
+	if _, err := io.ReadFull(p, soi); err != nil {
+		return false, nil, fmt.Errorf("jpeg: reading SOI: %w", err)
+	}
+	if soi[0] != 0xFF || soi[1] != 0xD8 {
+		return false, nil, fmt.Errorf("jpeg: not a valid JPEG file")
+	}
+	if _, err := dst.Write(soi); err != nil {
+		return false, nil, fmt.Errorf("jpeg: writing SOI: %w", err)
+	}
+
+	type held struct {
+		en           uint16
+		start, end   int
+		header, data []byte
+	}
+	var heldAPP11 []held
+	byEn := map[uint16][]jumbfPart{}
+	removed := false
+	var infos []segment.Info
+
+	flushAPP11 := func() error {
+		drop := make(map[uint16]bool, len(byEn))
+		uuids := make(map[uint16]string, len(byEn))
+		for en, parts := range byEn {
+			box, err := reassembleJUMBF(parts)
+			if err != nil || !box.IsC2PA() {
+				continue
+			}
+			drop[en] = true
+			if desc, ok := box.Description(); ok {
+				if uuid, ok := desc.UUID(); ok {
+					uuids[en] = fmt.Sprintf("%x", uuid[:])
+				}
+			}
+		}
+		for _, h := range heldAPP11 {
+			if drop[h.en] {
+				removed = true
+				infos = append(infos, segment.Info{Kind: "jumbf", Offset: h.start, Length: h.end - h.start, UUID: uuids[h.en]})
+				continue
+			}
+			if _, err := dst.Write(h.header); err != nil {
+				return err
+			}
+			if _, err := dst.Write(h.data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for {
+		markerType, start, err := readMarker(p)
+		if err != nil {
+			return removed, infos, flushAPP11()
+		}
+
+		if markerType == 0xDA || markerType == 0xD9 { // SOS or EOI
+			if err := flushAPP11(); err != nil {
+				return removed, infos, err
+			}
+			if _, err := dst.Write([]byte{0xFF, markerType}); err != nil {
+				return removed, infos, err
+			}
+			if markerType == 0xD9 {
+				return removed, infos, nil
+			}
+			if _, err := io.Copy(dst, p); err != nil {
+				return removed, infos, fmt.Errorf("jpeg: copying scan data: %w", err)
+			}
+			return removed, infos, nil
+		}
+		if (markerType >= 0xD0 && markerType <= 0xD7) || markerType == 0x01 {
+			if _, err := dst.Write([]byte{0xFF, markerType}); err != nil {
+				return removed, infos, err
+			}
+			continue
+		}
+
+		lenBuf := make([]byte, 2)
+		if _, err := io.ReadFull(p, lenBuf); err != nil {
+			return removed, infos, flushAPP11()
+		}
+		length := int(binary.BigEndian.Uint16(lenBuf))
+		if length < 2 {
+			// Malformed length: nothing after this point can be parsed
+			// reliably, so stop inspecting and copy the rest of src through
+			// unchanged. Unlike parseSegments, which resyncs to the next
+			// plausible marker, no segment after this point is inspected
+			// or removed.
+			if err := flushAPP11(); err != nil {
+				return removed, infos, err
+			}
+			if _, err := dst.Write(append([]byte{0xFF, markerType}, lenBuf...)); err != nil {
+				return removed, infos, err
+			}
+			if _, err := io.Copy(dst, p); err != nil {
+				return removed, infos, fmt.Errorf("jpeg: copying data after malformed segment: %w", err)
+			}
+			return removed, infos, nil
+		}
+		payload := make([]byte, length-2)
+		if _, err := io.ReadFull(p, payload); err != nil {
+			return removed, infos, flushAPP11()
+		}
+		header := append([]byte{0xFF, markerType}, lenBuf...)
+
+		switch markerType {
+		case 0xEB: // APP11: hold until the group's fate is known
+			en := uint16(0)
+			if len(payload) >= 8 && string(payload[0:2]) == "JP" {
+				en = binary.BigEndian.Uint16(payload[2:4])
+				z := binary.BigEndian.Uint32(payload[4:8])
+				byEn[en] = append(byEn[en], jumbfPart{z: z, payload: payload[8:]})
+			}
+			heldAPP11 = append(heldAPP11, held{en: en, start: start, end: p.pos, header: header, data: payload})
+		case 0xE1: // APP1: edit or drop a C2PA-carrying XMP packet
+			if xmpHasC2PA(payload) {
+				removed = true
+				infos = append(infos, segment.Info{
+					Kind:          "xmp",
+					Offset:        start,
+					Length:        p.pos - start,
+					XMPProperties: xmpC2PAProperties(payload),
+				})
+				if edited, ok := editXMPSegment(payload, preserveMetadata); ok {
+					if _, err := dst.Write(edited); err != nil {
+						return removed, infos, err
+					}
+				} else {
+				}
+				continue
+			}
+			if _, err := dst.Write(header); err != nil {
+				return removed, infos, err
+			}
+			if _, err := dst.Write(payload); err != nil {
+				return removed, infos, err
+			}
+		default:
+			if _, err := dst.Write(header); err != nil {
+				return removed, infos, err
+			}
+			if _, err := dst.Write(payload); err != nil {
+				return removed, infos, err
+			}
+		}
+	}
+}
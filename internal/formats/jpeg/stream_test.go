@@ -0,0 +1,144 @@
+package jpeg
+
+import (
+	"bytes"
+	"testing"
+)
+
+// appendSegment appends a single marker segment (marker byte, 2-byte
+// length, payload) to buf; markerType must be one that carries a length
+// field (i.e. not SOI/EOI/RST/TEM).
+func appendSegment(buf []byte, markerType byte, payload []byte) []byte {
+	length := uint16(2 + len(payload))
+	buf = append(buf, 0xFF, markerType)
+	buf = append(buf, byte(length>>8), byte(length))
+	return append(buf, payload...)
+}
+
+// appendAPP11 appends one APP11 (JUMBF) marker segment carrying En/Z plus
+// a slice of some larger box's bytes.
+func appendAPP11(buf []byte, en uint16, z uint32, boxPart []byte) []byte {
+	payload := append([]byte("JP"), byte(en>>8), byte(en))
+	payload = append(payload, byte(z>>24), byte(z>>16), byte(z>>8), byte(z))
+	payload = append(payload, boxPart...)
+	return appendSegment(buf, 0xEB, payload)
+}
+
+// minimalC2PABox builds the smallest JUMBF box jumbf.Box.IsC2PA accepts
+// directly (a box whose own type starts with "c2pa"), padded with filler
+// payload bytes so a test can split it across several APP11 segments.
+func minimalC2PABox(fillerLen int) []byte {
+	lbox := uint32(8 + fillerLen)
+	box := []byte{byte(lbox >> 24), byte(lbox >> 16), byte(lbox >> 8), byte(lbox)}
+	box = append(box, []byte("c2pa")...)
+	return append(box, bytes.Repeat([]byte{0xAB}, fillerLen)...)
+}
+
+// buildJPEG assembles SOI + segments + SOS + scanData + EOI.
+func buildJPEG(segments [][]byte, scanData []byte) []byte {
+	data := []byte{0xFF, 0xD8}
+	for _, s := range segments {
+		data = append(data, s...)
+	}
+	data = append(data, 0xFF, 0xDA) // SOS
+	data = append(data, scanData...)
+	data = append(data, 0xFF, 0xD9) // EOI
+	return data
+}
+
+func TestDetectStreamMatchesCheckForSingleSegmentJUMBF(t *testing.T) {
+	var segs [][]byte
+	segs = append(segs, appendAPP11(nil, 1, 0, minimalC2PABox(0)))
+	data := buildJPEG(segs, bytes.Repeat([]byte{0x42}, 4096))
+
+	if !Check(data) {
+		t.Fatal("Check() = false, want true")
+	}
+	has, _, err := DetectStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DetectStream() error: %v", err)
+	}
+	if !has {
+		t.Fatal("DetectStream() = false, want true")
+	}
+}
+
+func TestDetectStreamReassemblesMultiSegmentJUMBF(t *testing.T) {
+	box := minimalC2PABox(40)
+	split := 24
+	var segs [][]byte
+	segs = append(segs, appendAPP11(nil, 7, 1, box[split:])) // out of Z order on purpose
+	segs = append(segs, appendAPP11(nil, 7, 0, box[:split]))
+	data := buildJPEG(segs, bytes.Repeat([]byte{0x42}, 256))
+
+	if !Check(data) {
+		t.Fatal("Check() = false, want true for a JUMBF manifest split across APP11 segments")
+	}
+	has, infos, err := DetectStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("DetectStream() error: %v", err)
+	}
+	if !has {
+		t.Fatal("DetectStream() = false, want true for a JUMBF manifest split across APP11 segments")
+	}
+	if len(infos) != 1 || infos[0].Kind != "jumbf" {
+		t.Fatalf("DetectStream() infos = %+v, want a single jumbf entry", infos)
+	}
+}
+
+func TestRemoveStreamMatchesRemoveMode(t *testing.T) {
+	box := minimalC2PABox(16)
+	var segs [][]byte
+	segs = append(segs, appendSegment(nil, 0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))) // APP0, unrelated
+	segs = append(segs, appendAPP11(nil, 3, 0, box))
+	scanData := bytes.Repeat([]byte{0x99}, 1<<20) // large enough to matter if it were buffered whole
+
+	data := buildJPEG(segs, scanData)
+
+	want, err := RemoveMode(data, true)
+	if err != nil {
+		t.Fatalf("RemoveMode() error: %v", err)
+	}
+
+	var out bytes.Buffer
+	removed, infos, err := RemoveStream(&out, bytes.NewReader(data), true)
+	if err != nil {
+		t.Fatalf("RemoveStream() error: %v", err)
+	}
+	if !removed {
+		t.Fatal("RemoveStream() removed = false, want true")
+	}
+	if len(infos) != 1 || infos[0].Kind != "jumbf" {
+		t.Fatalf("RemoveStream() infos = %+v, want a single jumbf entry", infos)
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Fatal("RemoveStream() output diverges from RemoveMode() on the same input")
+	}
+	if Check(out.Bytes()) {
+		t.Fatal("RemoveStream() left C2PA content detectable in its output")
+	}
+}
+
+func TestRemoveStreamPreservesNonC2PAXMP(t *testing.T) {
+	xmp := append(append([]byte{}, xmpURIPrefix...), []byte("<x:xmpmeta>dc:creator=test</x:xmpmeta>")...)
+	var segs [][]byte
+	segs = append(segs, appendSegment(nil, 0xE1, xmp))
+	data := buildJPEG(segs, bytes.Repeat([]byte{0x11}, 128))
+
+	want, err := RemoveMode(data, true)
+	if err != nil {
+		t.Fatalf("RemoveMode() error: %v", err)
+	}
+
+	var out bytes.Buffer
+	removed, infos, err := RemoveStream(&out, bytes.NewReader(data), true)
+	if err != nil {
+		t.Fatalf("RemoveStream() error: %v", err)
+	}
+	if removed || len(infos) != 0 {
+		t.Fatalf("RemoveStream() removed=%v infos=%+v, want nothing touched for non-C2PA XMP", removed, infos)
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Fatal("RemoveStream() output diverges from RemoveMode() for a non-C2PA XMP segment")
+	}
+}
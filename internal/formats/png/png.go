@@ -0,0 +1,134 @@
+// Package png provides C2PA detection/removal for PNG images, which carry
+// C2PA manifests either as a raw JUMBF box in a "caBX" chunk (the primary
+// embedding per the C2PA spec) or, less commonly, as text describing a
+// manifest in an iTXt/tEXt chunk.
+package png
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ngmisl/C2PAremover/internal/jumbf"
+	"github.com/ngmisl/C2PAremover/internal/segment"
+)
+
+// Chunk represents a single PNG chunk.
+type Chunk struct {
+	Offset int // offset of the chunk's length field, i.e. the start of the chunk
+	Length uint32
+	Type   string
+	Data   []byte
+	CRC    uint32
+}
+
+// ExtractChunks extracts every chunk from a PNG file, in order.
+func ExtractChunks(data []byte) []Chunk {
+	var chunks []Chunk
+	pos := 8 // skip the 8-byte PNG signature
+
+	for pos+12 <= len(data) {
+		offset := pos
+		length := uint32(data[pos])<<24 | uint32(data[pos+1])<<16 | uint32(data[pos+2])<<8 | uint32(data[pos+3])
+		pos += 4
+
+		chunkType := string(data[pos : pos+4])
+		pos += 4
+
+		if pos+int(length)+4 > len(data) {
+			fmt.Printf("PNG chunk truncated (%s, length %d)\n", chunkType, length)
+			break
+		}
+
+		chunkData := data[pos : pos+int(length)]
+		pos += int(length)
+
+		crc := uint32(data[pos])<<24 | uint32(data[pos+1])<<16 | uint32(data[pos+2])<<8 | uint32(data[pos+3])
+		pos += 4
+
+		chunks = append(chunks, Chunk{Offset: offset, Length: length, Type: chunkType, Data: chunkData, CRC: crc})
+
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	return chunks
+}
+
+// Inspect reports every C2PA-carrying chunk in a PNG: the "caBX" JUMBF box,
+// if present, and any iTXt/tEXt chunk mentioning a manifest.
+func Inspect(data []byte) []segment.Info {
+	var infos []segment.Info
+	for _, c := range ExtractChunks(data) {
+		if isC2PAChunk(c) {
+			infos = append(infos, segment.Info{Kind: "png-chunk", Offset: c.Offset, Length: 12 + int(c.Length)})
+		}
+	}
+	return infos
+}
+
+// isC2PAChunk reports whether c carries C2PA content: a "caBX" chunk whose
+// payload is a C2PA JUMBF box, or an iTXt/tEXt chunk mentioning one.
+func isC2PAChunk(c Chunk) bool {
+	switch c.Type {
+	case "caBX":
+		box, err := jumbf.ParseBox(c.Data)
+		return err == nil && box.IsC2PA()
+	case "iTXt", "tEXt":
+		lower := strings.ToLower(string(c.Data))
+		return strings.Contains(lower, "c2pa") ||
+			strings.Contains(lower, "contentauthenticity") ||
+			strings.Contains(lower, "cai:")
+	default:
+		return false
+	}
+}
+
+// Check reports whether data (a PNG image) carries a C2PA "caBX" JUMBF box
+// or a C2PA-mentioning iTXt/tEXt chunk.
+func Check(data []byte) bool {
+	for _, c := range ExtractChunks(data) {
+		if isC2PAChunk(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove strips C2PA "caBX" and iTXt/tEXt chunks from a PNG, copying every
+// other chunk through byte-for-byte.
+func Remove(data []byte) ([]byte, error) {
+	chunks := ExtractChunks(data)
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("png: failed to parse PNG chunks")
+	}
+
+	buf := new(bytes.Buffer)
+	_, _ = buf.Write([]byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A})
+
+	removed := false
+	for _, c := range chunks {
+		if isC2PAChunk(c) {
+			removed = true
+			continue
+		}
+
+		lengthBytes := []byte{byte(c.Length >> 24), byte(c.Length >> 16), byte(c.Length >> 8), byte(c.Length)}
+		_, _ = buf.Write(lengthBytes)
+		_, _ = buf.Write([]byte(c.Type))
+		_, _ = buf.Write(c.Data)
+		crcBytes := []byte{byte(c.CRC >> 24), byte(c.CRC >> 16), byte(c.CRC >> 8), byte(c.CRC)}
+		_, _ = buf.Write(crcBytes)
+	}
+
+	if !removed {
+		return data, nil
+	}
+
+	cleaned := buf.Bytes()
+	if Check(cleaned) {
+		return data, fmt.Errorf("png: removal failed verification check")
+	}
+
+	return cleaned, nil
+}
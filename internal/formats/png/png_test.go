@@ -0,0 +1,80 @@
+package png
+
+import "testing"
+
+// pngSignature is the fixed 8-byte PNG file signature.
+var pngSignature = []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}
+
+// appendChunk appends a single PNG chunk (length, type, data, crc) to buf.
+// The CRC is never verified by this package, so a placeholder is fine.
+func appendChunk(buf []byte, chunkType string, data []byte) []byte {
+	length := uint32(len(data))
+	buf = append(buf, byte(length>>24), byte(length>>16), byte(length>>8), byte(length))
+	buf = append(buf, []byte(chunkType)...)
+	buf = append(buf, data...)
+	return append(buf, 0, 0, 0, 0) // placeholder CRC
+}
+
+// minimalJUMBFC2PA builds the smallest JUMBF box jumbf.Box.IsC2PA accepts:
+// a single box whose own type starts with "c2pa".
+func minimalJUMBFC2PA() []byte {
+	box := []byte{0, 0, 0, 8} // LBox = 8 (header only, no payload)
+	box = append(box, []byte("c2pa")...)
+	return box
+}
+
+func buildPNG(extra ...[2]string) []byte {
+	data := append([]byte{}, pngSignature...)
+	ihdr := make([]byte, 13)
+	data = appendChunk(data, "IHDR", ihdr)
+	for _, e := range extra {
+		var chunkData []byte
+		if e[0] == "caBX" {
+			chunkData = minimalJUMBFC2PA()
+		} else {
+			chunkData = []byte(e[1])
+		}
+		data = appendChunk(data, e[0], chunkData)
+	}
+	data = appendChunk(data, "IEND", nil)
+	return data
+}
+
+func TestCheckDetectsCaBX(t *testing.T) {
+	data := buildPNG([2]string{"caBX", ""})
+	if !Check(data) {
+		t.Fatal("Check() = false, want true for a PNG carrying a caBX C2PA box")
+	}
+}
+
+func TestCheckIgnoresPlainFile(t *testing.T) {
+	data := buildPNG()
+	if Check(data) {
+		t.Fatal("Check() = true, want false for a PNG with no C2PA content")
+	}
+}
+
+func TestRemoveStripsCaBXWithoutFalsePositive(t *testing.T) {
+	data := buildPNG([2]string{"caBX", ""}, [2]string{"tEXt", "Comment\x00hello world"})
+
+	cleaned, err := Remove(data)
+	if err != nil {
+		t.Fatalf("Remove() error: %v", err)
+	}
+	if Check(cleaned) {
+		t.Fatal("Remove() left C2PA content detectable in the cleaned PNG")
+	}
+
+	var sawTEXt bool
+	for _, c := range ExtractChunks(cleaned) {
+		if c.Type == "caBX" {
+			t.Fatal("Remove() left the caBX chunk in place")
+		}
+		if c.Type == "tEXt" {
+			sawTEXt = true
+		}
+	}
+	if !sawTEXt {
+		t.Fatal("Remove() dropped an unrelated tEXt chunk it should have kept")
+	}
+}
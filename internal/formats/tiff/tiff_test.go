@@ -0,0 +1,148 @@
+package tiff
+
+import (
+	"bytes"
+	"testing"
+)
+
+// ifdEntry is the raw content of one 12-byte TIFF directory entry, used by
+// buildTIFF to assemble a synthetic file.
+type ifdEntry struct {
+	tag       uint16
+	fieldType uint16
+	count     uint32
+	value     uint32 // inline value, left-justified in the 4-byte field like a real encoder
+}
+
+// buildTIFF assembles a minimal little-endian TIFF with a single IFD
+// holding entries, each written inline (callers should only pass entries
+// whose type/count fit in 4 bytes).
+func buildTIFF(entries []ifdEntry) []byte {
+	const ifdOff = 8
+	data := []byte{'I', 'I', '*', 0, byte(ifdOff), 0, 0, 0}
+
+	count := uint16(len(entries))
+	data = append(data, byte(count), byte(count>>8))
+	for _, e := range entries {
+		entryBytes := make([]byte, 12)
+		entryBytes[0], entryBytes[1] = byte(e.tag), byte(e.tag>>8)
+		entryBytes[2], entryBytes[3] = byte(e.fieldType), byte(e.fieldType>>8)
+		entryBytes[4], entryBytes[5], entryBytes[6], entryBytes[7] =
+			byte(e.count), byte(e.count>>8), byte(e.count>>16), byte(e.count>>24)
+		entryBytes[8], entryBytes[9], entryBytes[10], entryBytes[11] =
+			byte(e.value), byte(e.value>>8), byte(e.value>>16), byte(e.value>>24)
+		data = append(data, entryBytes...)
+	}
+	data = append(data, 0, 0, 0, 0) // next IFD offset: none
+	return data
+}
+
+func TestRemoveShiftsLaterIFDEntriesUp(t *testing.T) {
+	entries := []ifdEntry{
+		{tag: 0x0100, fieldType: 3, count: 1, value: 640},        // ImageWidth, before the C2PA tag
+		{tag: c2paManifestTag, fieldType: 4, count: 1, value: 0}, // C2PA manifest tag
+		{tag: 0x0101, fieldType: 3, count: 1, value: 480},        // ImageLength, after the C2PA tag
+	}
+	data := buildTIFF(entries)
+
+	if !Check(data) {
+		t.Fatal("Check() = false, want true for a TIFF carrying the C2PA manifest tag")
+	}
+
+	cleaned, err := Remove(data)
+	if err != nil {
+		t.Fatalf("Remove() error: %v", err)
+	}
+	if Check(cleaned) {
+		t.Fatal("Remove() left the C2PA manifest tag in place")
+	}
+
+	h, err := parseHeader(cleaned)
+	if err != nil {
+		t.Fatalf("parseHeader(cleaned) error: %v", err)
+	}
+	if len(h.entries) != 2 {
+		t.Fatalf("IFD has %d entries after Remove(), want 2", len(h.entries))
+	}
+
+	width, ok := h.find(0x0100)
+	if !ok {
+		t.Fatal("ImageWidth entry missing after Remove()")
+	}
+	widthBytes, _ := h.valueBytes(cleaned, width)
+	if got := h.order.Uint16(widthBytes); got != 640 {
+		t.Fatalf("ImageWidth = %d, want 640 (entry corrupted by the shift)", got)
+	}
+
+	length, ok := h.find(0x0101)
+	if !ok {
+		t.Fatal("ImageLength entry missing after Remove() (shift dropped the entry after the removed one)")
+	}
+	lengthBytes, _ := h.valueBytes(cleaned, length)
+	if got := h.order.Uint16(lengthBytes); got != 480 {
+		t.Fatalf("ImageLength = %d, want 480 (shifted entry now reads the wrong bytes)", got)
+	}
+}
+
+// buildChainedTIFF assembles a TIFF with a trailing fake IFD1 (just a
+// recognizable byte pattern, not a parseable IFD) after the real IFD0, with
+// IFD0's next-IFD-offset field pointing at it, so Remove's buffer-shrink
+// and next-IFD-offset adjustment can both be verified directly.
+func buildChainedTIFF(entries []ifdEntry, ifd1 []byte) []byte {
+	const ifdOff = 8
+	data := []byte{'I', 'I', '*', 0, byte(ifdOff), 0, 0, 0}
+
+	count := uint16(len(entries))
+	data = append(data, byte(count), byte(count>>8))
+	for _, e := range entries {
+		entryBytes := make([]byte, 12)
+		entryBytes[0], entryBytes[1] = byte(e.tag), byte(e.tag>>8)
+		entryBytes[2], entryBytes[3] = byte(e.fieldType), byte(e.fieldType>>8)
+		entryBytes[4], entryBytes[5], entryBytes[6], entryBytes[7] =
+			byte(e.count), byte(e.count>>8), byte(e.count>>16), byte(e.count>>24)
+		entryBytes[8], entryBytes[9], entryBytes[10], entryBytes[11] =
+			byte(e.value), byte(e.value>>8), byte(e.value>>16), byte(e.value>>24)
+		data = append(data, entryBytes...)
+	}
+
+	ifd1Off := uint32(len(data) + 4) // right after the next-IFD-offset field itself
+	data = append(data, byte(ifd1Off), byte(ifd1Off>>8), byte(ifd1Off>>16), byte(ifd1Off>>24))
+	data = append(data, ifd1...)
+	return data
+}
+
+func TestRemoveShrinksBufferAndAdjustsNextIFDOffset(t *testing.T) {
+	entries := []ifdEntry{
+		{tag: 0x0100, fieldType: 3, count: 1, value: 640},
+		{tag: c2paManifestTag, fieldType: 4, count: 1, value: 0},
+	}
+	ifd1 := []byte{0xDE, 0xAD, 0xBE, 0xEF}
+	data := buildChainedTIFF(entries, ifd1)
+
+	cleaned, err := Remove(data)
+	if err != nil {
+		t.Fatalf("Remove() error: %v", err)
+	}
+
+	if len(cleaned) != len(data)-12 {
+		t.Fatalf("len(cleaned) = %d, want %d (the removed entry's 12 bytes should actually be gone, not left as a stale gap)", len(cleaned), len(data)-12)
+	}
+
+	h, err := parseHeader(cleaned)
+	if err != nil {
+		t.Fatalf("parseHeader(cleaned) error: %v", err)
+	}
+	if len(h.entries) != 1 {
+		t.Fatalf("IFD has %d entries after Remove(), want 1", len(h.entries))
+	}
+
+	entriesStart := int(h.ifdOff) + 2
+	nextIFDPos := entriesStart + len(h.entries)*12
+	gotNextIFD := h.order.Uint32(cleaned[nextIFDPos : nextIFDPos+4])
+	if int(gotNextIFD)+len(ifd1) > len(cleaned) {
+		t.Fatalf("next-IFD-offset = %d, points past the end of the cleaned file (len %d)", gotNextIFD, len(cleaned))
+	}
+	if !bytes.Equal(cleaned[gotNextIFD:int(gotNextIFD)+len(ifd1)], ifd1) {
+		t.Fatalf("next-IFD-offset = %d does not point at the shifted IFD1 bytes %x", gotNextIFD, ifd1)
+	}
+}
@@ -0,0 +1,84 @@
+// Package webp provides minimal C2PA detection/removal for WebP images,
+// which carry C2PA manifests in a top-level RIFF "C2PA" chunk.
+package webp
+
+import (
+	"encoding/binary"
+
+	"github.com/ngmisl/C2PAremover/internal/segment"
+)
+
+type chunk struct {
+	start int // offset of the chunk's FourCC
+	size  int // chunk size from its header, excluding header and padding
+}
+
+// findC2PAChunk scans the RIFF chunk stream for a "C2PA" FourCC chunk.
+func findC2PAChunk(data []byte) (chunk, bool) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return chunk{}, false
+	}
+
+	pos := 12
+	for pos+8 <= len(data) {
+		fourCC := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		if size < 0 || pos+8+size > len(data) {
+			break
+		}
+		if fourCC == "C2PA" {
+			return chunk{start: pos, size: size}, true
+		}
+		pos += 8 + size
+		if size%2 == 1 { // RIFF chunks are padded to an even size
+			pos++
+		}
+	}
+	return chunk{}, false
+}
+
+// Check reports whether data (a WebP image) carries a C2PA RIFF chunk.
+func Check(data []byte) bool {
+	_, found := findC2PAChunk(data)
+	return found
+}
+
+// Inspect reports the C2PA RIFF chunk in a WebP image, if any.
+func Inspect(data []byte) []segment.Info {
+	c, found := findC2PAChunk(data)
+	if !found {
+		return nil
+	}
+	length := 8 + c.size
+	if c.size%2 == 1 {
+		length++
+	}
+	return []segment.Info{{Kind: "riff-chunk", Offset: c.start, Length: length}}
+}
+
+// Remove strips the C2PA RIFF chunk from a WebP image, copying every other
+// chunk through byte-for-byte and fixing up the outer RIFF size field.
+//
+// The WebP extended header (VP8X) has no C2PA-specific flag bit in the
+// spec - its flag bits are reserved for ICCP/EXIF/XMP/animation/alpha - so
+// there's nothing to clear there when the C2PA chunk is removed.
+func Remove(data []byte) ([]byte, error) {
+	c, found := findC2PAChunk(data)
+	if !found {
+		return data, nil
+	}
+
+	chunkLen := 8 + c.size
+	if c.size%2 == 1 {
+		chunkLen++
+	}
+
+	result := make([]byte, 0, len(data)-chunkLen)
+	result = append(result, data[:c.start]...)
+	result = append(result, data[c.start+chunkLen:]...)
+
+	newRIFFSize := uint32(len(result) - 8)
+	binary.LittleEndian.PutUint32(result[4:8], newRIFFSize)
+
+	return result, nil
+}
@@ -0,0 +1,191 @@
+// Package jumbf parses the JPEG Universal Metadata Box Format (JUMBF)
+// structure carried in JPEG APP11 marker segments, so callers can identify
+// and strip only the C2PA-carrying boxes rather than every APP11 segment,
+// which is also used by JPEG XT, JPEG 360 and other box-based extensions.
+package jumbf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// C2PAUUID is the JUMBF description-box content-type UUID C2PA manifests
+// are tagged with: "c2pa" followed by the fixed ISO/IEC 19566-5 baseline
+// UUID tail (0x6332706100110010800000AA00389B71).
+var C2PAUUID = [16]byte{
+	0x63, 0x32, 0x70, 0x61, 0x00, 0x11, 0x00, 0x10,
+	0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71,
+}
+
+// Segment is the parsed header and payload box of a single APP11 marker
+// segment.
+type Segment struct {
+	En  uint16 // box instance number
+	Z   uint32 // packet sequence number
+	Box Box
+}
+
+// Box is a node in the ISO BMFF-derived JUMBF box tree.
+type Box struct {
+	LBox    uint32
+	TBox    string
+	XLBox   uint64 // only set when LBox == 1
+	Payload []byte // raw box payload, including any nested boxes
+	Boxes   []Box  // sub-boxes, populated when TBox == "jumb"
+}
+
+// Size returns the box's real size in bytes (LBox, or XLBox when LBox==1).
+func (b Box) Size() uint64 {
+	if b.LBox == 1 {
+		return b.XLBox
+	}
+	return uint64(b.LBox)
+}
+
+// Description returns the box's description sub-box (TBox "jumd"), if any.
+func (b Box) Description() (Box, bool) {
+	for _, sub := range b.Boxes {
+		if sub.TBox == "jumd" {
+			return sub, true
+		}
+	}
+	return Box{}, false
+}
+
+// UUID returns the 16-byte content-type UUID carried by a "jumd" box.
+func (b Box) UUID() ([16]byte, bool) {
+	if b.TBox != "jumd" || len(b.Payload) < 16 {
+		return [16]byte{}, false
+	}
+	var uuid [16]byte
+	copy(uuid[:], b.Payload[:16])
+	return uuid, true
+}
+
+// Label returns the optional null-terminated label string carried by a
+// "jumd" box, if its toggles byte marks one as present.
+func (b Box) Label() (string, bool) {
+	if b.TBox != "jumd" || len(b.Payload) < 17 {
+		return "", false
+	}
+	const labelPresent = 0x02
+	if b.Payload[16]&labelPresent == 0 {
+		return "", false
+	}
+	rest := b.Payload[17:]
+	for i, c := range rest {
+		if c == 0 {
+			return string(rest[:i]), true
+		}
+	}
+	return string(rest), true
+}
+
+// IsC2PA reports whether a box is a C2PA manifest, either because its own
+// box type starts with "c2pa" (as produced by some JUMBF writers) or
+// because it's a "jumb" superbox whose description box identifies it as
+// C2PA by UUID or by a "c2pa" label prefix.
+func (b Box) IsC2PA() bool {
+	if strings.HasPrefix(b.TBox, "c2pa") {
+		return true
+	}
+	if b.TBox != "jumb" {
+		return false
+	}
+	desc, ok := b.Description()
+	if !ok {
+		return false
+	}
+	if uuid, ok := desc.UUID(); ok && uuid == C2PAUUID {
+		return true
+	}
+	if label, ok := desc.Label(); ok && strings.HasPrefix(label, "c2pa") {
+		return true
+	}
+	return false
+}
+
+// ParseBox parses a single ISO BMFF box (LBox/TBox[/XLBox]/payload) from
+// the start of data, recursing into sub-boxes when TBox == "jumb". This is
+// the same box format JUMBF uses inside APP11, so it's also useful for
+// walking "jumb" boxes embedded natively in BMFF-based containers
+// (HEIF/AVIF/MP4).
+func ParseBox(data []byte) (Box, error) {
+	return parseBox(data)
+}
+
+// ParseAPP11 parses the payload of a single JPEG APP11 marker segment
+// (everything after the marker and its 2-byte length). It returns the En
+// and Z header fields plus the single top-level JUMBF box they carry.
+func ParseAPP11(payload []byte) (*Segment, error) {
+	if len(payload) < 8 || string(payload[0:2]) != "JP" {
+		return nil, fmt.Errorf("jumbf: not a JPEG-box APP11 segment (missing \"JP\" CI)")
+	}
+	en := binary.BigEndian.Uint16(payload[2:4])
+	z := binary.BigEndian.Uint32(payload[4:8])
+
+	box, err := parseBox(payload[8:])
+	if err != nil {
+		return nil, err
+	}
+	return &Segment{En: en, Z: z, Box: box}, nil
+}
+
+// parseBox parses a single ISO BMFF box (LBox/TBox[/XLBox]/payload) from
+// the start of data, recursing into sub-boxes when TBox == "jumb".
+func parseBox(data []byte) (Box, error) {
+	if len(data) < 8 {
+		return Box{}, fmt.Errorf("jumbf: box header truncated")
+	}
+	lbox := binary.BigEndian.Uint32(data[0:4])
+	tbox := string(data[4:8])
+
+	pos := 8
+	size := uint64(lbox)
+	var xlbox uint64
+	if lbox == 1 {
+		if len(data) < 16 {
+			return Box{}, fmt.Errorf("jumbf: extended box header truncated")
+		}
+		xlbox = binary.BigEndian.Uint64(data[8:16])
+		pos = 16
+		size = xlbox
+	}
+	if size == 0 {
+		size = uint64(len(data)) // box extends to the end of its container
+	}
+	if size > uint64(len(data)) {
+		return Box{}, fmt.Errorf("jumbf: box %q length %d exceeds available data (%d)", tbox, size, len(data))
+	}
+	if size < uint64(pos) {
+		return Box{}, fmt.Errorf("jumbf: box %q length %d is shorter than its header", tbox, size)
+	}
+
+	box := Box{LBox: lbox, TBox: tbox, XLBox: xlbox, Payload: data[pos:size]}
+	if tbox == "jumb" {
+		box.Boxes = parseSubBoxes(box.Payload)
+	}
+	return box, nil
+}
+
+// parseSubBoxes walks a sequence of sibling boxes packed back-to-back,
+// e.g. the jumd/content boxes inside a jumb superbox. A malformed trailing
+// box is dropped rather than treated as fatal, so a partial tree is still
+// usable.
+func parseSubBoxes(data []byte) []Box {
+	var boxes []Box
+	for len(data) >= 8 {
+		box, err := parseBox(data)
+		if err != nil {
+			break
+		}
+		boxes = append(boxes, box)
+		consumed := int(box.Size())
+		if consumed <= 0 || consumed > len(data) {
+			break
+		}
+		data = data[consumed:]
+	}
+	return boxes
+}
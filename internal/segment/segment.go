@@ -0,0 +1,22 @@
+// Package segment defines the descriptor format-specific Inspect functions
+// use to report identified C2PA regions back to pkg/c2paremover's Report.
+// It exists so each internal/formats/* package can describe what it found
+// without importing pkg/c2paremover, which imports them.
+package segment
+
+// Info describes one region of a source image that was identified as
+// carrying C2PA content.
+type Info struct {
+	// Kind names the region's shape in format-specific terms, e.g. "jumbf",
+	// "xmp", "uuid", "png-chunk", "riff-chunk", "application-extension",
+	// "ifd-tag".
+	Kind   string
+	Offset int
+	Length int
+	// UUID is the JUMBF content-type or BMFF binding UUID, hex-encoded,
+	// when the region carries one.
+	UUID string
+	// XMPProperties lists the C2PA element/attribute names found in an XMP
+	// packet (e.g. "xmlns:c2pa", "c2pa:manifest"), when the region is one.
+	XMPProperties []string
+}
@@ -11,7 +11,7 @@ import (
 func TestCheckC2PA(t *testing.T) {
 	tests := []struct {
 		name     string
-		testFile string
+		testFile []byte
 		expected bool
 	}{
 		{
@@ -38,14 +38,9 @@ func TestCheckC2PA(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			var data []byte
-			if tc.testFile == nil {
+			data := tc.testFile
+			if data == nil {
 				data = []byte{}
-			} else if tc.testFile != nil && len(tc.testFile) > 0 && tc.testFile[0] == 0 {
-				// If the first byte is 0, interpret as raw data
-				data = tc.testFile
-			} else {
-				data = tc.testFile
 			}
 
 			result := CheckC2PA(data)
@@ -0,0 +1,338 @@
+// Package c2paremover is the importable core of the c2paremover tool: it
+// detects and strips C2PA metadata from JPEG, PNG, WebP, BMFF-based
+// (HEIF/AVIF/MP4/MOV), TIFF and GIF images without requiring callers to
+// shell out to the CLI. Detect/Strip read from an io.Reader/io.Writer pair
+// so the library can sit in front of an upload handler or image pipeline.
+//
+// For JPEG and BMFF, Detect is bounded-memory: their format-specific
+// packages walk marker/box headers and skip the opaque payload that dwarfs
+// everything else in a real file - compressed scan data after JPEG's SOS,
+// and BMFF's mdat - without ever buffering it. Strip is bounded-memory the
+// same way for JPEG in ModePreserve, copying the scan data straight through
+// to the writer. Every other case (PNG, WebP, TIFF and GIF, BMFF in Strip,
+// and JPEG under ModeReencode) reads the whole input before inspecting it.
+package c2paremover
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/ngmisl/C2PAremover/internal/formats/bmff"
+	"github.com/ngmisl/C2PAremover/internal/formats/gif"
+	fjpeg "github.com/ngmisl/C2PAremover/internal/formats/jpeg"
+	fpng "github.com/ngmisl/C2PAremover/internal/formats/png"
+	"github.com/ngmisl/C2PAremover/internal/formats/tiff"
+	"github.com/ngmisl/C2PAremover/internal/formats/webp"
+	"github.com/ngmisl/C2PAremover/internal/segment"
+)
+
+// sniffBufSize bounds the buffered reader used to sniff a file's magic
+// bytes before deciding how to handle the rest of the stream.
+const sniffBufSize = 64 * 1024
+
+// Mode selects the strategy Strip uses to remove C2PA metadata from a
+// JPEG or PNG; it has no effect on the other container formats, which
+// have no pixel re-encode path and are always handled structurally.
+type Mode int
+
+const (
+	// ModePreserve walks the container's segment/chunk tree and drops only
+	// the C2PA-carrying segments, leaving everything else (ICC profiles,
+	// EXIF, DPI, ancillary chunks) byte-for-byte intact. Default mode.
+	ModePreserve Mode = iota
+	// ModeReencode decodes and re-encodes the image via the standard
+	// library. This also strips C2PA metadata, but discards every other
+	// ancillary chunk/segment and requantizes JPEG pixel data. Kept as a
+	// last-resort option for files the structural parser can't handle.
+	ModeReencode
+)
+
+// Options configures Strip.
+type Options struct {
+	Mode Mode
+	// PreserveMetadata, when true, surgically removes only C2PA content
+	// from a metadata block that mixes it with other data (e.g. an XMP
+	// packet that also carries copyright or capture time), instead of
+	// dropping the whole block. The CLI defaults this to true; the zero
+	// Options value leaves it false (the simpler, more aggressive
+	// behavior) since Options has no other way to mean "unset". Only
+	// JPEG's APP1 XMP handling has an aggressive mode to opt into; every
+	// other format's removal is already this conservative unconditionally.
+	PreserveMetadata bool
+}
+
+// Segment describes one region of a source image identified as carrying
+// C2PA content - an alias for segment.Info so callers can inspect a
+// Report's Segments without importing an internal package.
+type Segment = segment.Info
+
+// Report summarizes what Detect/Strip found.
+type Report struct {
+	Format  string
+	HasC2PA bool
+	// Segments enumerates every region identified as C2PA content - each
+	// JUMBF box, XMP packet, or format-specific chunk/tag - so a caller can
+	// log or audit what was found before (or instead of) mutating the file.
+	Segments []Segment
+}
+
+// SniffFormat returns a short format name ("jpeg", "png", "webp", "bmff",
+// "tiff", "gif") for data's magic bytes, or "" if unrecognized.
+func SniffFormat(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return "jpeg"
+	case bytes.HasPrefix(data, []byte{0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A}):
+		return "png"
+	case len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return "webp"
+	case len(data) >= 12 && bytes.Equal(data[4:8], []byte("ftyp")) && isBMFFBrand(data[8:12]):
+		return "bmff"
+	case bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")):
+		return "tiff"
+	case bytes.HasPrefix(data, []byte("GIF87a")) || bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif"
+	default:
+		return ""
+	}
+}
+
+// isBMFFBrand reports whether a 4-byte ftyp major brand belongs to a
+// HEIF/AVIF/MP4/MOV family container.
+func isBMFFBrand(brand []byte) bool {
+	switch string(brand) {
+	case "heic", "heix", "hevc", "hevx", "mif1", "msf1", "avif", "avis",
+		"isom", "iso2", "mp41", "mp42", "M4A ", "M4V ", "qt  ":
+		return true
+	default:
+		return false
+	}
+}
+
+// sniffPeek is how many leading bytes Detect/Strip peek at to identify the
+// format before deciding how to handle the rest of the stream; it covers
+// every SniffFormat case (the widest is BMFF's 12-byte ftyp check).
+const sniffPeek = 64
+
+// Detect reads r and reports whether the image it contains carries C2PA
+// metadata.
+//
+// For JPEG and BMFF, r's compressed scan data / mdat payload is never
+// buffered: Detect peeks r's magic bytes to pick a format, then hands the
+// same *bufio.Reader straight to that format's DetectStream, which reads
+// only the marker/box headers and the handful of metadata payloads that
+// might carry a manifest. Every other format still reads r in full before
+// inspecting it.
+func Detect(r io.Reader) (Report, error) {
+	br := bufio.NewReaderSize(r, sniffBufSize)
+	head, _ := br.Peek(sniffPeek) // fewer bytes on a short stream is fine
+
+	format := SniffFormat(head)
+	if format == "" {
+		return Report{}, fmt.Errorf("c2paremover: unsupported image format")
+	}
+
+	switch format {
+	case "jpeg":
+		has, segs, err := fjpeg.DetectStream(br)
+		if err != nil {
+			return Report{Format: format}, fmt.Errorf("c2paremover: %w", err)
+		}
+		return Report{Format: format, HasC2PA: has, Segments: segs}, nil
+	case "bmff":
+		has, segs, err := bmff.DetectStream(br)
+		if err != nil {
+			return Report{Format: format}, fmt.Errorf("c2paremover: %w", err)
+		}
+		return Report{Format: format, HasC2PA: has, Segments: segs}, nil
+	default:
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return Report{}, fmt.Errorf("c2paremover: reading input: %w", err)
+		}
+		return Report{Format: format, HasC2PA: check(data, format), Segments: inspect(data, format)}, nil
+	}
+}
+
+// Strip reads src, removes any C2PA metadata per opts, and writes the
+// cleaned image to dst.
+//
+// For JPEG in the default ModePreserve mode, this is bounded-memory the
+// same way Detect is: src's compressed scan data is copied straight to dst
+// without being buffered. That streaming path can't re-read src, so it
+// skips the verify-and-fall-back-to-re-encode safety net removeImage gives
+// PNG: its output is not re-checked, and C2PA content the structural pass
+// doesn't recognize is left in place without an error or a re-encode.
+// Every other format, and JPEG
+// when ModeReencode is requested explicitly, still reads src in full.
+func Strip(dst io.Writer, src io.Reader, opts Options) (Report, error) {
+	br := bufio.NewReaderSize(src, sniffBufSize)
+	head, _ := br.Peek(sniffPeek)
+
+	format := SniffFormat(head)
+	if format == "" {
+		return Report{}, fmt.Errorf("c2paremover: unsupported image format")
+	}
+
+	if format == "jpeg" && opts.Mode == ModePreserve {
+		has, segs, err := fjpeg.RemoveStream(dst, br, opts.PreserveMetadata)
+		report := Report{Format: format, HasC2PA: has, Segments: segs}
+		if err != nil {
+			return report, fmt.Errorf("c2paremover: %w", err)
+		}
+		return report, nil
+	}
+
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return Report{}, fmt.Errorf("c2paremover: reading input: %w", err)
+	}
+	report := Report{Format: format, HasC2PA: check(data, format), Segments: inspect(data, format)}
+
+	cleaned, err := remove(data, format, opts.Mode, opts.PreserveMetadata)
+	if err != nil {
+		return report, err
+	}
+	if _, err := dst.Write(cleaned); err != nil {
+		return report, fmt.Errorf("c2paremover: writing output: %w", err)
+	}
+	return report, nil
+}
+
+// Check is the byte-slice convenience form of Detect, for callers that
+// already have the whole image in memory.
+func Check(data []byte) (Report, error) {
+	return Detect(bytes.NewReader(data))
+}
+
+// Remove is the byte-slice convenience form of Strip, for callers that
+// already have the whole image in memory.
+func Remove(data []byte, opts Options) ([]byte, Report, error) {
+	var buf bytes.Buffer
+	report, err := Strip(&buf, bytes.NewReader(data), opts)
+	return buf.Bytes(), report, err
+}
+
+// CheckReader is an alias for Detect, matching the Check/Remove naming
+// convention for callers that want the io.Reader-based form by that name.
+func CheckReader(r io.Reader) (Report, error) {
+	return Detect(r)
+}
+
+// RemoveWriter is Strip with its src/dst parameters in Check/Remove order,
+// for callers that want the streaming form by that name.
+func RemoveWriter(src io.Reader, dst io.Writer, opts Options) (Report, error) {
+	return Strip(dst, src, opts)
+}
+
+func check(data []byte, format string) bool {
+	switch format {
+	case "jpeg":
+		return fjpeg.Check(data)
+	case "png":
+		return fpng.Check(data)
+	case "webp":
+		return webp.Check(data)
+	case "bmff":
+		return bmff.Check(data)
+	case "tiff":
+		return tiff.Check(data)
+	case "gif":
+		return gif.Check(data)
+	default:
+		return false
+	}
+}
+
+// inspect returns the detail behind check's yes/no verdict: every region of
+// data identified as C2PA content, for Report.Segments.
+func inspect(data []byte, format string) []Segment {
+	switch format {
+	case "jpeg":
+		return fjpeg.Inspect(data)
+	case "png":
+		return fpng.Inspect(data)
+	case "webp":
+		return webp.Inspect(data)
+	case "bmff":
+		return bmff.Inspect(data)
+	case "tiff":
+		return tiff.Inspect(data)
+	case "gif":
+		return gif.Inspect(data)
+	default:
+		return nil
+	}
+}
+
+func remove(data []byte, format string, mode Mode, preserveMetadata bool) ([]byte, error) {
+	switch format {
+	case "jpeg", "png":
+		return removeImage(data, format, mode, preserveMetadata)
+	case "webp":
+		return webp.Remove(data)
+	case "bmff":
+		return bmff.Remove(data)
+	case "tiff":
+		return tiff.Remove(data)
+	case "gif":
+		return gif.Remove(data)
+	default:
+		return nil, fmt.Errorf("c2paremover: unsupported image format: %s", format)
+	}
+}
+
+// removeImage removes C2PA metadata from a JPEG or PNG using the given
+// Mode. preserveMetadata only affects JPEG's APP1 XMP handling; PNG
+// removal already preserves every non-C2PA chunk unconditionally.
+func removeImage(data []byte, format string, mode Mode, preserveMetadata bool) ([]byte, error) {
+	if mode == ModeReencode {
+		return removeReencode(data, format)
+	}
+
+	var (
+		cleaned []byte
+		err     error
+	)
+	switch format {
+	case "jpeg":
+		cleaned, err = fjpeg.RemoveMode(data, preserveMetadata)
+	case "png":
+		cleaned, err = fpng.Remove(data)
+	}
+
+	if err == nil && !check(cleaned, format) {
+		return cleaned, nil
+	}
+	return removeReencode(data, format)
+}
+
+// removeReencode strips C2PA (and all other) metadata by decoding the
+// image and re-encoding it with the standard library. This is lossy for
+// JPEG and drops ICC profiles, EXIF and ancillary chunks, so it is only
+// used when structural removal (ModePreserve) isn't possible.
+func removeReencode(data []byte, format string) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("re-encode fallback: decoding image failed: %w", err)
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95})
+	case "png":
+		err = png.Encode(&buf, img)
+	default:
+		return nil, fmt.Errorf("re-encode fallback: unsupported format: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("re-encode fallback: encoding image failed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
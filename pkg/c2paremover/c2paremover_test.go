@@ -0,0 +1,58 @@
+package c2paremover
+
+import (
+	"bytes"
+	"testing"
+)
+
+// minimalC2PAJPEG builds a tiny JPEG carrying a single-segment C2PA JUMBF
+// box in an APP11 marker, followed by scanData as its compressed scan data.
+func minimalC2PAJPEG(scanData []byte) []byte {
+	box := []byte{0, 0, 0, 8} // LBox = 8 (header only, no payload)
+	box = append(box, []byte("c2pa")...)
+
+	payload := []byte("JP\x00\x01\x00\x00\x00\x00") // En=1, Z=0
+	payload = append(payload, box...)
+	length := uint16(2 + len(payload))
+
+	data := []byte{0xFF, 0xD8} // SOI
+	data = append(data, 0xFF, 0xEB, byte(length>>8), byte(length))
+	data = append(data, payload...)
+	data = append(data, 0xFF, 0xDA) // SOS
+	data = append(data, scanData...)
+	data = append(data, 0xFF, 0xD9) // EOI
+	return data
+}
+
+func TestDetectDispatchesToJPEGStream(t *testing.T) {
+	data := minimalC2PAJPEG(bytes.Repeat([]byte{0x42}, 256))
+
+	report, err := Detect(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Detect() error: %v", err)
+	}
+	if report.Format != "jpeg" || !report.HasC2PA {
+		t.Fatalf("Detect() = %+v, want jpeg format with HasC2PA", report)
+	}
+}
+
+func TestStripDispatchesToJPEGStream(t *testing.T) {
+	data := minimalC2PAJPEG(bytes.Repeat([]byte{0x99}, 256))
+
+	var out bytes.Buffer
+	report, err := Strip(&out, bytes.NewReader(data), Options{Mode: ModePreserve, PreserveMetadata: true})
+	if err != nil {
+		t.Fatalf("Strip() error: %v", err)
+	}
+	if !report.HasC2PA {
+		t.Fatalf("Strip() report = %+v, want HasC2PA", report)
+	}
+
+	cleaned, cleanedReport, err := Remove(out.Bytes(), Options{Mode: ModePreserve, PreserveMetadata: true})
+	if err != nil {
+		t.Fatalf("Remove() on Strip's output error: %v", err)
+	}
+	if cleanedReport.HasC2PA {
+		t.Fatalf("Strip() left C2PA content detectable in its output: %+v", cleaned)
+	}
+}
@@ -0,0 +1,354 @@
+//go:build !wasmer
+// +build !wasmer
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ngmisl/C2PAremover/pkg/c2paremover"
+)
+
+// OutputMode selects how Scanner results are rendered.
+type OutputMode string
+
+const (
+	OutputText   OutputMode = "text"
+	OutputJSON   OutputMode = "json"
+	OutputNDJSON OutputMode = "ndjson"
+	OutputCSV    OutputMode = "csv"
+)
+
+// ScanResult is one file's scan outcome.
+type ScanResult struct {
+	Path            string   `json:"path"`
+	Format          string   `json:"format"`
+	HasC2PA         bool     `json:"hasC2PA"`
+	DetectedMarkers []string `json:"detectedMarkers,omitempty"`
+	Sidecars        []string `json:"sidecars,omitempty"`
+	BytesRead       int64    `json:"bytesRead"`
+	ElapsedMS       int64    `json:"elapsedMs"`
+	Fixed           bool     `json:"fixed,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+// Scanner walks a directory tree checking (and optionally fixing) images
+// for C2PA metadata, using a bounded worker pool so it scales to
+// directories with thousands of files.
+type Scanner struct {
+	Workers   int           // size of the worker pool; defaults to 4
+	Recursive bool          // descend into subdirectories
+	Include   []string      // glob patterns matched against the base name; empty matches everything
+	Exclude   []string      // glob patterns matched against the base name
+	Output    OutputMode    // text, json, ndjson or csv; defaults to text
+	Fix       bool          // run removal inline, rotating the original to ".bak"
+	Timeout   time.Duration // per-file timeout; 0 disables it
+}
+
+// scannerFromArgs parses check-dir's trailing CLI flags into a Scanner.
+func scannerFromArgs(args []string) Scanner {
+	s := Scanner{Workers: 4, Output: OutputText}
+	for _, a := range args {
+		switch {
+		case a == "--recursive":
+			s.Recursive = true
+		case a == "--fix":
+			s.Fix = true
+		case strings.HasPrefix(a, "--workers="):
+			if n, err := strconv.Atoi(strings.TrimPrefix(a, "--workers=")); err == nil && n > 0 {
+				s.Workers = n
+			}
+		case strings.HasPrefix(a, "--output="):
+			s.Output = OutputMode(strings.TrimPrefix(a, "--output="))
+		case strings.HasPrefix(a, "--include="):
+			s.Include = append(s.Include, strings.Split(strings.TrimPrefix(a, "--include="), ",")...)
+		case strings.HasPrefix(a, "--exclude="):
+			s.Exclude = append(s.Exclude, strings.Split(strings.TrimPrefix(a, "--exclude="), ",")...)
+		case strings.HasPrefix(a, "--timeout="):
+			if d, err := time.ParseDuration(strings.TrimPrefix(a, "--timeout=")); err == nil {
+				s.Timeout = d
+			}
+		}
+	}
+	return s
+}
+
+// Scan walks root (optionally recursively), checking every matching image
+// with a bounded pool of Workers goroutines, and returns every ScanResult.
+func (s Scanner) Scan(root string) ([]ScanResult, error) {
+	if s.Workers <= 0 {
+		s.Workers = 4
+	}
+	if s.Output == "" {
+		s.Output = OutputText
+	}
+
+	paths := make(chan string, s.Workers*2)
+	results := make(chan ScanResult, s.Workers*2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range paths {
+				results <- s.scanFileWithTimeout(p)
+			}
+		}()
+	}
+
+	var walkErr error
+	go func() {
+		defer close(paths)
+		walkErr = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				fmt.Println("Error walking", path, err)
+				return nil
+			}
+			if d.IsDir() {
+				if !s.Recursive && path != root {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !s.matches(path) {
+				return nil
+			}
+			paths <- path
+			return nil
+		})
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var all []ScanResult
+	for r := range results {
+		if s.Output == OutputNDJSON {
+			s.emitOne(r)
+		}
+		all = append(all, r)
+	}
+
+	if s.Output != OutputNDJSON {
+		s.emitAll(all)
+	}
+	s.emitSummary(all)
+	return all, walkErr
+}
+
+// matches reports whether path should be scanned: it must have a
+// supported image extension, satisfy Include (if set), and not satisfy
+// Exclude.
+func (s Scanner) matches(path string) bool {
+	name := filepath.Base(path)
+	if !isSupportedImageExt(filepath.Ext(name)) {
+		return false
+	}
+	if len(s.Include) > 0 && !matchesAny(s.Include, name) {
+		return false
+	}
+	if matchesAny(s.Exclude, name) {
+		return false
+	}
+	return true
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pat := range patterns {
+		if ok, _ := filepath.Match(pat, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// scanFileWithTimeout runs scanFile, bounding it to s.Timeout if set. On
+// timeout the underlying scan keeps running in the background (CheckC2PA
+// has no cancellation hook), but the worker moves on to the next path
+// instead of blocking the pool.
+func (s Scanner) scanFileWithTimeout(path string) ScanResult {
+	if s.Timeout <= 0 {
+		return s.scanFile(path)
+	}
+
+	done := make(chan ScanResult, 1)
+	go func() { done <- s.scanFile(path) }()
+
+	select {
+	case r := <-done:
+		return r
+	case <-time.After(s.Timeout):
+		return ScanResult{Path: path, Error: fmt.Sprintf("scan timed out after %s", s.Timeout)}
+	}
+}
+
+// scanFile reads and checks a single file, optionally fixing it in place.
+func (s Scanner) scanFile(path string) ScanResult {
+	start := time.Now()
+	res := ScanResult{Path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		res.Error = err.Error()
+		res.ElapsedMS = time.Since(start).Milliseconds()
+		return res
+	}
+
+	res.BytesRead = int64(len(data))
+	res.Format = c2paremover.SniffFormat(data)
+	res.HasC2PA = CheckC2PA(data)
+	if res.HasC2PA {
+		res.DetectedMarkers = detectedMarkers(res.Format)
+	}
+	if sidecars, err := FindSidecars(path); err == nil {
+		res.Sidecars = sidecars
+	}
+
+	if s.Fix && res.HasC2PA {
+		if err := fixFileInPlace(path, data); err != nil {
+			res.Error = err.Error()
+		} else {
+			res.Fixed = true
+		}
+	}
+
+	res.ElapsedMS = time.Since(start).Milliseconds()
+	return res
+}
+
+// detectedMarkers gives a short, human-readable hint of where C2PA data
+// was found for the given format.
+func detectedMarkers(format string) []string {
+	switch format {
+	case "jpeg":
+		return []string{"APP11 JUMBF / XMP C2PA segment"}
+	case "png":
+		return []string{"caBX JUMBF box / iTXt/tEXt C2PA chunk"}
+	case "webp":
+		return []string{"C2PA RIFF chunk"}
+	case "bmff":
+		return []string{"uuid/jumb C2PA box"}
+	case "tiff":
+		return []string{"C2PA IFD tag or XMP"}
+	case "gif":
+		return []string{"C2PA application extension"}
+	default:
+		return nil
+	}
+}
+
+// fixFileInPlace removes C2PA metadata from path, rotating the original to
+// a ".bak" sibling before writing the cleaned data over it.
+func fixFileInPlace(path string, data []byte) error {
+	cleaned, err := RemoveC2PA(data)
+	if err != nil {
+		return fmt.Errorf("removing C2PA metadata: %w", err)
+	}
+
+	bakPath := path + ".bak"
+	if err := os.Rename(path, bakPath); err != nil {
+		return fmt.Errorf("backing up original: %w", err)
+	}
+	if err := os.WriteFile(path, cleaned, 0644); err != nil {
+		_ = os.Rename(bakPath, path) // best-effort restore
+		return fmt.Errorf("writing cleaned file: %w", err)
+	}
+	return nil
+}
+
+// emitOne prints a single result as it's produced (used for ndjson, which
+// streams one JSON object per line).
+func (s Scanner) emitOne(r ScanResult) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		fmt.Println("Error encoding result:", err)
+		return
+	}
+	fmt.Println(string(b))
+}
+
+// emitAll prints the full result set once scanning has finished.
+func (s Scanner) emitAll(results []ScanResult) {
+	switch s.Output {
+	case OutputJSON:
+		b, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			fmt.Println("Error encoding results:", err)
+			return
+		}
+		fmt.Println(string(b))
+	case OutputCSV:
+		w := csv.NewWriter(os.Stdout)
+		_ = w.Write([]string{"path", "format", "hasC2PA", "detectedMarkers", "sidecars", "bytesRead", "elapsedMs", "fixed", "error"})
+		for _, r := range results {
+			_ = w.Write(csvRow(r))
+		}
+		w.Flush()
+	default: // text
+		for _, r := range results {
+			status := "✓ No C2PA metadata"
+			if r.HasC2PA {
+				status = "⚠️  C2PA metadata detected"
+			}
+			fmt.Printf("%s: %s\n", r.Path, status)
+			if r.Error != "" {
+				fmt.Println("   error:", r.Error)
+			}
+			if len(r.Sidecars) > 0 {
+				fmt.Printf("   found %d C2PA sidecar file(s)\n", len(r.Sidecars))
+			}
+			if r.Fixed {
+				fmt.Println("   fixed (original backed up with .bak)")
+			}
+		}
+	}
+}
+
+func csvRow(r ScanResult) []string {
+	return []string{
+		r.Path,
+		r.Format,
+		strconv.FormatBool(r.HasC2PA),
+		strings.Join(r.DetectedMarkers, ";"),
+		strings.Join(r.Sidecars, ";"),
+		strconv.FormatInt(r.BytesRead, 10),
+		strconv.FormatInt(r.ElapsedMS, 10),
+		strconv.FormatBool(r.Fixed),
+		r.Error,
+	}
+}
+
+// emitSummary prints the end-of-run summary. Structured output modes skip
+// it so piped output stays parseable.
+func (s Scanner) emitSummary(results []ScanResult) {
+	if s.Output == OutputJSON || s.Output == OutputCSV || s.Output == OutputNDJSON {
+		return
+	}
+
+	var withC2PA, fixed int
+	for _, r := range results {
+		if r.HasC2PA {
+			withC2PA++
+		}
+		if r.Fixed {
+			fixed++
+		}
+	}
+	fmt.Printf("\nSummary: Checked %d images, found C2PA metadata in %d images", len(results), withC2PA)
+	if fixed > 0 {
+		fmt.Printf(", fixed %d", fixed)
+	}
+	fmt.Println()
+}